@@ -0,0 +1,217 @@
+package box
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ItemRef identifies a file or folder for bulk operations.
+type ItemRef struct {
+	Type string `json:"type"` // ItemTypeFile or ItemTypeFolder
+	ID   string `json:"id"`
+}
+
+// How many alternative names to try when renaming on a name conflict
+var MaxConflictRenames = 20
+
+// How many times a bulk operation retries a request Box rate limited (429) or
+// was temporarily unavailable for (503), waiting as long as Box asks each time
+var MaxRateLimitRetries = 5
+
+// forEachConcurrent calls fn(i) for every i in [0, n), running at most
+// concurrency calls at once, and returns when all calls have finished.
+func forEachConcurrent(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// MoveItems moves files and folders into destFolderID, running up to
+// concurrency moves at once. The result holds an entry for every item, nil on
+// success. If renameOnConflict is set, an item whose name is already taken in
+// the destination is moved under a numbered name instead, e.g. "a (1).txt".
+// Each move attempt is retried while Box rate limits it or is unavailable.
+func (c *Client) MoveItems(items []ItemRef, destFolderID string, concurrency int, renameOnConflict bool) (map[ItemRef]error, error) {
+	if destFolderID == "" {
+		return nil, errors.New("No destFolderID provided")
+	}
+	for _, item := range items {
+		if item.ID == "" || !(item.Type == ItemTypeFile || item.Type == ItemTypeFolder) {
+			return nil, fmt.Errorf("Invalid item: %+v", item)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[ItemRef]error, len(items))
+	)
+	forEachConcurrent(len(items), concurrency, func(i int) {
+		err := c.moveItem(items[i], destFolderID, renameOnConflict)
+		mu.Lock()
+		results[items[i]] = err
+		mu.Unlock()
+	})
+
+	return results, nil
+}
+
+func (c *Client) moveItem(item ItemRef, destFolderID string, renameOnConflict bool) error {
+	move := func(newName string) error {
+		return withRateLimitRetry(func() error {
+			var err error
+			if item.Type == ItemTypeFile {
+				_, err = c.FileMove(item.ID, destFolderID, newName)
+			} else {
+				_, err = c.FolderMove(item.ID, destFolderID, newName)
+			}
+			return err
+		})
+	}
+
+	err := move("")
+	if !renameOnConflict || !isConflict(err) {
+		return err
+	}
+
+	// Look up the current name to derive alternatives from
+	var name string
+	if item.Type == ItemTypeFile {
+		fr, err := c.FileGetRef(item.ID)
+		if err != nil {
+			return err
+		}
+		name = fr.Name
+	} else {
+		fe, err := c.FoldersGetFolder(item.ID)
+		if err != nil {
+			return err
+		}
+		name = fe.Name
+	}
+
+	for n := 1; n <= MaxConflictRenames; n++ {
+		err = move(conflictName(name, n, item.Type == ItemTypeFile))
+		if !isConflict(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// withRateLimitRetry calls fn, calling it again whenever it fails because Box
+// rate limited it (429) or was temporarily unavailable (503), up to
+// MaxRateLimitRetries times. Each retry waits for the error's RetryAfter or,
+// if that isn't set, DefaultRateLimitRetryAfter or the longer
+// DefaultServiceUnavailableRetryAfter.
+func withRateLimitRetry(fn func() error) error {
+	err := fn()
+	for n := 0; n < MaxRateLimitRetries; n++ {
+		var ae *APIError
+		if !errors.As(err, &ae) {
+			break
+		}
+
+		wait := ae.RetryAfter
+		switch ae.Status {
+		case http.StatusTooManyRequests:
+			if wait <= 0 {
+				wait = DefaultRateLimitRetryAfter
+			}
+		case http.StatusServiceUnavailable:
+			if wait <= 0 {
+				wait = DefaultServiceUnavailableRetryAfter
+			}
+		default:
+			return err
+		}
+
+		time.Sleep(wait)
+		err = fn()
+	}
+	return err
+}
+
+func isConflict(err error) bool {
+	ae, ok := err.(*APIError)
+	return ok && ae.Status == http.StatusConflict
+}
+
+// conflictingItem returns the existing item a 409 error reports as already
+// using the requested name, or nil if the error doesn't name one. Box sends
+// the conflict as an object for files and as a list for folders.
+func conflictingItem(err error) *ItemRef {
+	ae, ok := err.(*APIError)
+	if !ok || ae.Status != http.StatusConflict || len(ae.ContextInfo) == 0 {
+		return nil
+	}
+
+	var ci struct {
+		Conflicts json.RawMessage `json:"conflicts"`
+	}
+	if err := json.Unmarshal(ae.ContextInfo, &ci); err != nil {
+		return nil
+	}
+
+	var item ItemRef
+	if err := json.Unmarshal(ci.Conflicts, &item); err != nil {
+		var items []ItemRef
+		if err := json.Unmarshal(ci.Conflicts, &items); err != nil || len(items) == 0 {
+			return nil
+		}
+		item = items[0]
+	}
+	if item.ID == "" {
+		return nil
+	}
+
+	return &item
+}
+
+// FilesGetInfoBatch fetches the information of many files, running up to
+// concurrency FileGetInfo calls at once with the given fields. Every ID ends
+// up in exactly one of the returned maps. Calls are retried while Box rate
+// limits them or is unavailable.
+func (c *Client) FilesGetInfoBatch(ids []string, fields []string, concurrency int) (map[string]*FileEntry, map[string]error) {
+	var (
+		mu      sync.Mutex
+		results = make(map[string]*FileEntry, len(ids))
+		errs    = map[string]error{}
+	)
+	forEachConcurrent(len(ids), concurrency, func(i int) {
+		var fe *FileEntry
+		err := withRateLimitRetry(func() error {
+			var err error
+			fe, err = c.FileGetInfo(ids[i], fields...)
+			return err
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[ids[i]] = err
+			return
+		}
+		results[ids[i]] = fe
+	})
+
+	return results, errs
+}
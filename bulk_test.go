@@ -0,0 +1,158 @@
+package box
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimitRetry(t *testing.T) {
+	origRateLimit, origUnavailable := DefaultRateLimitRetryAfter, DefaultServiceUnavailableRetryAfter
+	DefaultRateLimitRetryAfter, DefaultServiceUnavailableRetryAfter = 10*time.Millisecond, 80*time.Millisecond
+	defer func() {
+		DefaultRateLimitRetryAfter, DefaultServiceUnavailableRetryAfter = origRateLimit, origUnavailable
+	}()
+
+	tests := []struct {
+		name     string
+		err      *APIError
+		minWait  time.Duration
+		maxWait  time.Duration
+		retried  bool
+		wantFail bool
+	}{
+		{"429 default backoff", &APIError{Status: http.StatusTooManyRequests}, 10 * time.Millisecond, 60 * time.Millisecond, true, false},
+		{"503 longer default backoff", &APIError{Status: http.StatusServiceUnavailable}, 80 * time.Millisecond, time.Second, true, false},
+		{"503 honors RetryAfter", &APIError{Status: http.StatusServiceUnavailable, RetryAfter: 30 * time.Millisecond}, 30 * time.Millisecond, 70 * time.Millisecond, true, false},
+		{"404 not retried", &APIError{Status: http.StatusNotFound}, 0, 10 * time.Millisecond, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			start := time.Now()
+			err := withRateLimitRetry(func() error {
+				calls++
+				if calls == 1 {
+					return tt.err
+				}
+				return nil
+			})
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantFail {
+				t.Errorf("err = %v, want failure %v", err, tt.wantFail)
+			}
+			if tt.retried != (calls == 2) {
+				t.Errorf("fn called %d times, retried should be %v", calls, tt.retried)
+			}
+			if elapsed < tt.minWait || elapsed > tt.maxWait {
+				t.Errorf("waited %v, want between %v and %v", elapsed, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+
+	// A 503 that persists is given up on after MaxRateLimitRetries retries
+	calls := 0
+	err := withRateLimitRetry(func() error {
+		calls++
+		return &APIError{Status: http.StatusServiceUnavailable, RetryAfter: time.Millisecond}
+	})
+	if !errors.Is(err, ErrServiceUnavailable) || calls != MaxRateLimitRetries+1 {
+		t.Errorf("persistent 503: err = %v after %d calls, want ErrServiceUnavailable after %d", err, calls, MaxRateLimitRetries+1)
+	}
+}
+
+func TestMoveItemsRetriesRateLimit(t *testing.T) {
+	origRateLimit := DefaultRateLimitRetryAfter
+	DefaultRateLimitRetryAfter = time.Millisecond
+	defer func() { DefaultRateLimitRetryAfter = origRateLimit }()
+
+	var (
+		mu    sync.Mutex
+		moves []string
+	)
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /files/1":
+			io.WriteString(w, `{"type":"file","id":"1","name":"a.txt"}`)
+		case "PUT /files/1":
+			var body struct {
+				Name string `json:"name"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			moves = append(moves, body.Name)
+			n := len(moves)
+			mu.Unlock()
+			switch n {
+			case 1, 3:
+				w.WriteHeader(http.StatusTooManyRequests)
+			case 2:
+				w.WriteHeader(http.StatusConflict)
+			default:
+				io.WriteString(w, `{"type":"file","id":"1","name":"a (1).txt"}`)
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+
+	item := ItemRef{Type: ItemTypeFile, ID: "1"}
+	results, err := c.MoveItems([]ItemRef{item}, "2", 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[item] != nil {
+		t.Errorf("move failed: %v", results[item])
+	}
+	want := []string{"", "", "a (1).txt", "a (1).txt"}
+	if fmt.Sprint(moves) != fmt.Sprint(want) {
+		t.Errorf("move attempts used names %q, want %q", moves, want)
+	}
+}
+
+func TestFilesGetInfoBatchRetriesRateLimit(t *testing.T) {
+	origRateLimit := DefaultRateLimitRetryAfter
+	DefaultRateLimitRetryAfter = time.Millisecond
+	defer func() { DefaultRateLimitRetryAfter = origRateLimit }()
+
+	var (
+		mu    sync.Mutex
+		calls = map[string]int{}
+	)
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/files/404":
+			w.WriteHeader(http.StatusNotFound)
+		case n == 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprintf(w, `{"type":"file","id":%q}`, r.URL.Path[len("/files/"):])
+		}
+	})
+	defer done()
+
+	results, errs := c.FilesGetInfoBatch([]string{"1", "2", "404"}, nil, 3)
+	if len(results) != 2 || results["1"] == nil || results["2"] == nil {
+		t.Errorf("results = %v, want files 1 and 2", results)
+	}
+	var ae *APIError
+	if len(errs) != 1 || !errors.As(errs["404"], &ae) || ae.Status != http.StatusNotFound {
+		t.Errorf("errs = %v, want only a not found error for 404", errs)
+	}
+	if calls["/files/1"] != 2 || calls["/files/404"] != 1 {
+		t.Errorf("calls = %v, want rate limited files fetched twice and the missing one once", calls)
+	}
+}
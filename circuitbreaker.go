@@ -0,0 +1,87 @@
+package box
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("Circuit breaker open: too many consecutive Box API failures, not sending request")
+
+// CircuitBreaker stops a Client from sending requests after Threshold
+// consecutive failures (transport errors, including timeouts, and 5xx
+// responses). While open, requests fail immediately with ErrCircuitOpen. Once
+// Cooldown has passed a single probe request is let through: if it succeeds
+// the breaker closes again, otherwise it stays open for another Cooldown.
+//
+// It's opt-in: set Client.CircuitBreaker to enable it. One breaker may be
+// shared by several clients.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	probe    uint64 // Numbers the probes, so only the current one clears probing
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+// Open reports whether the breaker is currently rejecting requests.
+func (cb *CircuitBreaker) Open() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.isOpen() && (cb.probing || time.Now().Before(cb.openedAt.Add(cb.Cooldown)))
+}
+
+func (cb *CircuitBreaker) isOpen() bool {
+	return cb.Threshold > 0 && cb.failures >= cb.Threshold
+}
+
+// allow reports whether a request may be sent. A request let through as the
+// probe gets a non-zero probe number, to be passed on to record.
+func (cb *CircuitBreaker) allow() (uint64, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !cb.isOpen() {
+		return 0, nil
+	}
+	if cb.probing || time.Now().Before(cb.openedAt.Add(cb.Cooldown)) {
+		return 0, ErrCircuitOpen
+	}
+
+	// Cooldown is over: let this request through as the probe
+	cb.probing = true
+	cb.probe++
+	return cb.probe, nil
+}
+
+// record counts the outcome of a request allow let through. Requests sent
+// before the breaker opened may still complete while a probe is out; only the
+// probe itself ends probing.
+func (cb *CircuitBreaker) record(probe uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if probe != 0 && probe == cb.probe {
+		cb.probing = false
+	}
+	if success {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.isOpen() {
+		// (Re)open, restarting the cooldown
+		cb.openedAt = time.Now()
+	}
+}
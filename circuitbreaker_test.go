@@ -0,0 +1,45 @@
+package box
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	cb := NewCircuitBreaker(2, 10*time.Millisecond)
+
+	// Three requests go out; two fail and open the breaker while the third
+	// is still in flight
+	for i := 0; i < 3; i++ {
+		if probe, err := cb.allow(); err != nil || probe != 0 {
+			t.Fatalf("request %d: probe = %d, err = %v; want a regular request", i, probe, err)
+		}
+	}
+	cb.record(0, false)
+	cb.record(0, false)
+	if _, err := cb.allow(); err != ErrCircuitOpen {
+		t.Fatalf("err = %v, want ErrCircuitOpen during the cooldown", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	probe, err := cb.allow()
+	if err != nil || probe == 0 {
+		t.Fatalf("probe = %d, err = %v; want a probe after the cooldown", probe, err)
+	}
+
+	// The straggler completing doesn't end the probe, so no second probe is
+	// let through even once another cooldown has passed
+	cb.record(0, false)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cb.allow(); err != ErrCircuitOpen {
+		t.Fatalf("err = %v, want ErrCircuitOpen while the probe is out", err)
+	}
+
+	cb.record(probe, true)
+	if cb.Open() {
+		t.Fatal("breaker still open after a successful probe")
+	}
+	if _, err := cb.allow(); err != nil {
+		t.Errorf("err = %v, want requests let through again", err)
+	}
+}
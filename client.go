@@ -2,13 +2,19 @@ package box
 
 import (
 	"bytes"
+	"crypto/rsa"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
@@ -18,7 +24,15 @@ var GrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer" // Via https://git
 var APIBaseURL = "https://api.box.com/2.0"
 var UploadBaseURL = "https://upload.box.com/api/2.0"
 var APITokenURL = "https://api.box.com/oauth2/token"
+var DefaultJWTExpirySeconds = 45
 
+// Version of this package, reported to Box in the default User-Agent
+var Version = "0.1.0"
+var DefaultUserAgent = "astockwell-box/" + Version + " (go)"
+
+// Client is safe for concurrent use by multiple goroutines. Its exported
+// fields are configuration: set them before sharing the client, and don't
+// modify them (or the DefaultHeaders map) while requests may be in flight.
 type Client struct {
 	ClientID                 string
 	clientSecret             string
@@ -29,8 +43,33 @@ type Client struct {
 	APIBaseURL               string
 	UploadBaseURL            string
 	SubType                  string
+	JWTExpirySeconds         int         // Lifetime of the JWT assertion; clamped to Box's allowed 1-60 second range
+	DeviceID                 string      // Sent as the Box-Device-ID header when set, for enterprise device trust policies
+	DeviceName               string      // Sent as the Box-Device-Name header when set
+	AsUserID                 string      // Sent as the As-User header when set, so requests act as (and uploads are owned by) that user
+	UserAgent                string      // Overrides DefaultUserAgent when set
+	DefaultHeaders           http.Header // Added to every request unless the request already sets that header; never overrides Authorization
+	CircuitBreaker           *CircuitBreaker
+	PageSizes                PageSizes    // Page sizes of list methods; zero values use the defaults
+	HTTPClient               *http.Client // Sends all requests, including token requests; http.DefaultClient if nil
+	VerifyTokenLocally       bool         // Verify each signed JWT assertion locally before sending it, to pinpoint key and signing problems
+	tokenMu                  sync.Mutex   // Guards lastToken and lastTokenRetrieved
 	lastToken                *OauthTokenResponse
 	lastTokenRetrieved       *time.Time
+	rateLimitMu              sync.Mutex // Guards lastRateLimit
+	lastRateLimit            RateLimitInfo
+}
+
+// RateLimitInfo holds the rate limit headers of the most recent response that
+// had any. Box sends them inconsistently, so fields are zero (and Limit and
+// Remaining -1) when a header was missing; Header has all of them as sent.
+type RateLimitInfo struct {
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the limit resets
+	RetryAfter time.Duration
+	Header     http.Header // The X-RateLimit-* and Retry-After headers
+	ReceivedAt time.Time
 }
 
 type OauthTokenResponse struct {
@@ -51,9 +90,122 @@ func NewClient(clientID, clientsecret, enterpriseID, jWTKeyID, rSAPrivateKeyPemF
 		APIBaseURL:               APIBaseURL,
 		UploadBaseURL:            UploadBaseURL,
 		SubType:                  "enterprise",
+		JWTExpirySeconds:         DefaultJWTExpirySeconds,
+		HTTPClient:               &http.Client{Transport: DefaultTransport()},
 	}, nil
 }
 
+// DefaultTransport returns a new transport tuned for talking to Box: it keeps
+// enough idle connections per host for concurrent bulk operations to reuse
+// them rather than churning through new ones, and bounds connection setup,
+// while leaving transfers themselves unlimited so large uploads and downloads
+// aren't cut off. NewClient uses it; customize a copy and set it on
+// Client.HTTPClient to change the settings.
+func DefaultTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12, // Box doesn't accept older versions
+		},
+	}
+}
+
+// Errors returned by Ping
+var (
+	ErrAuthentication = errors.New("Box authentication failed")
+	ErrUnreachable    = errors.New("Box API unreachable")
+)
+
+// Ping makes a minimal authenticated request, refreshing the access token if
+// needed, to check the client can reach and use the Box API, e.g. for health
+// checks. On failure the error wraps ErrUnreachable for network problems, or
+// ErrAuthentication when Box rejects the credentials (a 400 or 401 from the
+// token endpoint, or a 401 for the request). Other errors, such as Box server
+// errors, are returned as they are: an *APIError, matching
+// ErrServiceUnavailable for a 503.
+func (c *Client) Ping() error {
+	if _, err := c.accessToken(false); err != nil {
+		return pingError(err)
+	}
+
+	var ue UserEntry
+	return pingError(c.DoJSON("GET", "users/me", url.Values{"fields": {"id"}}, nil, &ue))
+}
+
+// pingError classifies an error met by Ping.
+func pingError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*url.Error); ok {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+
+	// Check for a token error first, as it unwraps to an APIError too
+	var te *tokenError
+	if errors.As(err, &te) {
+		if te.ae.Status == http.StatusBadRequest || te.ae.Status == http.StatusUnauthorized {
+			return fmt.Errorf("%w: %v", ErrAuthentication, err)
+		}
+		return err
+	}
+	var ae *APIError
+	if errors.As(err, &ae) && ae.Status == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %v", ErrAuthentication, err)
+	}
+
+	return err
+}
+
+// ValidateConfig checks the client's configuration without contacting Box, so
+// a bad setup is reported clearly at startup instead of as an opaque OAuth
+// failure on the first request. Every problem found is listed in the error.
+func (c *Client) ValidateConfig() error {
+	problems := []string{}
+
+	if c.ClientID == "" {
+		problems = append(problems, "ClientID is empty")
+	}
+	if c.clientSecret == "" {
+		problems = append(problems, "client secret is empty")
+	}
+	if c.EnterpriseID == "" {
+		problems = append(problems, "EnterpriseID is empty")
+	}
+	if !stringInSlice(c.SubType, []string{"enterprise", "user"}) {
+		problems = append(problems, fmt.Sprintf("SubType %q is not \"enterprise\" or \"user\"", c.SubType))
+	}
+	if c.GrantType == "" {
+		problems = append(problems, "GrantType is empty")
+	}
+	if c.JWTKeyID == "" {
+		problems = append(problems, "JWTKeyID (the public key ID registered in Box) is empty")
+	}
+	if c.RSAPrivateKeyPemFilePath == "" {
+		problems = append(problems, "RSAPrivateKeyPemFilePath is empty")
+	} else if privateKeyPem, err := ioutil.ReadFile(c.RSAPrivateKeyPemFilePath); err != nil {
+		problems = append(problems, fmt.Sprintf("private key file can't be read: %v", err))
+	} else if _, err := jwt.ParseRSAPrivateKeyFromPEM(privateKeyPem); err != nil {
+		problems = append(problems, fmt.Sprintf("private key file %s is not a valid unencrypted PEM RSA private key: %v", c.RSAPrivateKeyPemFilePath, err))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid Box client configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// refreshAccessToken gets a new access token. c.tokenMu must be held.
 func (c *Client) refreshAccessToken() error {
 	// log.Println("Refreshing access token")
 	tokenRequested := time.Now()
@@ -64,6 +216,16 @@ func (c *Client) refreshAccessToken() error {
 		return err
 	}
 
+	// Box only accepts an exp of up to 60 seconds beyond the issue time
+	expirySeconds := c.JWTExpirySeconds
+	if expirySeconds == 0 {
+		expirySeconds = DefaultJWTExpirySeconds
+	} else if expirySeconds < 1 {
+		expirySeconds = 1
+	} else if expirySeconds > 60 {
+		expirySeconds = 60
+	}
+
 	// Box JWT Claims reference: https://developer.box.com/v2.0/docs/construct-jwt-claim-manually#section-6-constructing-the-claims
 	// TODO: allow for sub type of 'enterprise' or 'user' and make struct generic (instead of c.EnterpriseID, should be c.Sub I guess???)
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
@@ -72,7 +234,7 @@ func (c *Client) refreshAccessToken() error {
 		"box_sub_type": c.SubType,                            // (string, required) "enterprise" or "user" depending on the type of token being requested in the sub claim.
 		"aud":          APITokenURL,                             // (string, required) Always “https://api.box.com/oauth2/token” for OAuth2 token requests
 		"jti":          jwtNonce,                                // (string, required) A universally unique identifier specified by the client for this JWT. This is a unique string that is at least 16 characters and at most 128 characters.
-		"exp":          time.Now().Add(30 * time.Second).Unix(), // (NumericDate, required) The unix time as to when this JWT will expire. This can be set to a maximum value of 60 seconds beyond the issue time. Note: It is recommended to set this value to less than the maximum allowed 60 seconds.
+		"exp":          time.Now().Add(time.Duration(expirySeconds) * time.Second).Unix(), // (NumericDate, required) The unix time as to when this JWT will expire. This can be set to a maximum value of 60 seconds beyond the issue time. Note: It is recommended to set this value to less than the maximum allowed 60 seconds.
 		// "iat":          "",                                 // (NumericDate, optional) Issued at time. The token cannot be used before this time.
 		// "nbf":          "",                                 // (NumericDate, optional) Not before. Specifies when the token will start being valid.
 	})
@@ -92,28 +254,41 @@ func (c *Client) refreshAccessToken() error {
 	// Sign and get the complete encoded token as a string using the secret
 	tokenString, err := token.SignedString(privateKey)
 	// fmt.Println(tokenString, err)
+	if err != nil {
+		return err
+	}
+
+	if c.VerifyTokenLocally {
+		if err := c.verifyAssertion(tokenString, &privateKey.PublicKey); err != nil {
+			return fmt.Errorf("Local JWT verification failed, not requesting an access token: %v", err)
+		}
+	}
 
 	// Remove from memory
 	privateKey = nil
 	privateKeyPem = []byte{}
 
 	// Get new access token from Oauth2 API
-	res, err := http.PostForm(APITokenURL, url.Values{
+	form := url.Values{
 		"grant_type":    {c.GrantType},
 		"client_id":     {c.ClientID},
 		"client_secret": {c.clientSecret},
 		"assertion":     {tokenString},
-	})
+	}
+	req, err := http.NewRequest("POST", APITokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// Sent through the same HTTP client as API requests, so proxy and
+	// timeout settings apply to authentication too
+	res, err := c.httpClient().Do(req)
 	if err != nil {
 		return err
 	}
 	if res.StatusCode != http.StatusOK {
-		buf := new(bytes.Buffer)
-		io.Copy(buf, res.Body)
-		res.Body.Close()
-		body := strings.TrimSpace(buf.String())
-		// fmt.Println(body)
-		return fmt.Errorf("Unexpected status code while retrieving new Oauth2 access token: [%v]. HTTP Response body: [%s]", res.StatusCode, body)
+		return &tokenError{newAPIError(res)}
 	}
 	// spew.Dump(res)
 
@@ -138,44 +313,454 @@ func (c *Client) refreshAccessToken() error {
 	return nil
 }
 
-func (c *Client) HttpDo(req *http.Request) (*http.Response, error) {
+// verifyAssertion checks a signed JWT assertion the way Box will, against the
+// public key of the key it was signed with, so signing and configuration
+// problems are reported locally instead of as an opaque OAuth error.
+func (c *Client) verifyAssertion(tokenString string, publicKey *rsa.PublicKey) error {
+	parsed, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if kid, _ := parsed.Header["kid"].(string); kid == "" || kid != c.JWTKeyID {
+		return fmt.Errorf("kid header %q doesn't match JWTKeyID %q", kid, c.JWTKeyID)
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return errors.New("unexpected claims type")
+	}
+	for _, claim := range []string{"iss", "sub", "box_sub_type", "aud", "jti"} {
+		if v, _ := claims[claim].(string); v == "" {
+			return fmt.Errorf("claim %q is empty", claim)
+		}
+	}
+
+	return nil
+}
+
+// TokenScopes returns the scopes the current access token is restricted to,
+// or nil if no token has been retrieved yet.
+func (c *Client) TokenScopes() []string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.lastToken == nil {
+		return nil
+	}
+	return append([]string{}, c.lastToken.RestrictedTo...)
+}
+
+// HasScope reports whether the current access token allows scope, e.g.
+// "manage_enterprise". A token whose RestrictedTo is empty isn't restricted
+// by Box and is taken to allow every scope the application was configured
+// with. Without a token yet, it reports false; see RequireScopes.
+func (c *Client) HasScope(scope string) bool {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.lastToken == nil {
+		return false
+	}
+	if len(c.lastToken.RestrictedTo) == 0 {
+		return true
+	}
+	return stringInSlice(scope, c.lastToken.RestrictedTo)
+}
+
+// RequireScopes checks up front that the access token allows all of scopes,
+// retrieving a token first if needed, so a missing permission is reported
+// clearly instead of as a 403 partway through an operation.
+func (c *Client) RequireScopes(scopes ...string) error {
+	if _, err := c.accessToken(false); err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for _, scope := range scopes {
+		if !c.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Access token lacks required scope(s): %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// accessToken returns a usable access token, refreshing it first if there is
+// none yet or (unless skipRefresh) it's about to expire. Concurrent callers
+// share a single refresh.
+func (c *Client) accessToken(skipRefresh bool) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
 	// check c.lastToken != nil and is not expired
 	// if nil or expired, get new one
 	if c.lastToken == nil || c.lastTokenRetrieved == nil {
 		err := c.refreshAccessToken()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-	} else {
+	} else if !skipRefresh {
 		lastTokenDuration, err := time.ParseDuration(fmt.Sprintf("%ds", c.lastToken.ExpiresIn-10))
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		if time.Now().After(c.lastTokenRetrieved.Add(lastTokenDuration)) {
 			err := c.refreshAccessToken()
 			if err != nil {
-				return nil, err
+				return "", err
 			}
 		}
 	}
 	// spew.Dump(c.lastToken)
 
+	return c.lastToken.AccessToken, nil
+}
+
+// replaceAccessToken refreshes the access token after Box rejected staleToken,
+// unless another goroutine already replaced it in the meantime.
+func (c *Client) replaceAccessToken(staleToken string) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.lastToken == nil || c.lastToken.AccessToken == staleToken {
+		if err := c.refreshAccessToken(); err != nil {
+			return "", err
+		}
+	}
+
+	return c.lastToken.AccessToken, nil
+}
+
+// RequestOption adjusts how HttpDo, Do and DoJSON send a single request.
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	skipTokenRefresh       bool
+	rawResponse            *[]byte
+	skipExternalOwnerCheck bool
+}
+
+// skipExternalOwnerCheck keeps Do from looking up whether the item of a 403
+// is externally owned; the lookup itself uses it, so it can't recurse.
+func skipExternalOwnerCheck() RequestOption {
+	return func(ro *requestOptions) {
+		ro.skipExternalOwnerCheck = true
+	}
+}
+
+// SkipTokenRefresh sends the request with the current access token even if
+// it may have expired, instead of proactively refreshing it first. The token
+// is still refreshed (and the request retried) if Box responds with 401. This
+// saves refresh bookkeeping for high volume, cheap calls such as existence
+// checks.
+func SkipTokenRefresh() RequestOption {
+	return func(ro *requestOptions) {
+		ro.skipTokenRefresh = true
+	}
+}
+
+// CaptureRawResponse stores the raw response body of a DoJSON call in dst, so
+// callers can read fields the typed structs don't model yet.
+func CaptureRawResponse(dst *[]byte) RequestOption {
+	return func(ro *requestOptions) {
+		ro.rawResponse = dst
+	}
+}
+
+func (c *Client) HttpDo(req *http.Request, opts ...RequestOption) (*http.Response, error) {
+	var ro requestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+
+	token, err := c.accessToken(ro.skipTokenRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	c.applyDefaultHeaders(req)
+
 	// make request with valid access token
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", c.lastToken.AccessToken))
-	resp, err := http.DefaultClient.Do(req)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+	resp, err := c.send(req)
 	if err != nil {
 		return resp, err
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
 		// log.Printf("Recieved (%s) response, retrying with new token\n", resp.Status)
-		err := c.refreshAccessToken()
+		token, err := c.replaceAccessToken(token)
+		if err != nil {
+			return nil, err
+		}
+
+		// The first attempt consumed the request body, get a fresh copy. A
+		// body that can't be rewound can't be resent, so return the 401 and
+		// leave retrying (with the new token) to the caller.
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		resp.Body.Close()
+
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+		return c.send(req)
+	}
+
+	return resp, nil
+}
+
+// send performs a single HTTP round trip, guarded by the circuit breaker when
+// one is configured.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	var probe uint64
+	if c.CircuitBreaker != nil {
+		var err error
+		if probe, err = c.CircuitBreaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if c.CircuitBreaker != nil {
+		c.CircuitBreaker.record(probe, err == nil && resp.StatusCode < http.StatusInternalServerError)
+	}
+	if err == nil {
+		c.recordRateLimit(resp)
+	}
+
+	return resp, err
+}
+
+// httpClient returns the HTTP client to send requests with.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// LastRateLimit returns the rate limit headers of the most recent response
+// that carried any, or the zero RateLimitInfo if none has yet.
+func (c *Client) LastRateLimit() RateLimitInfo {
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	return c.lastRateLimit
+}
+
+func (c *Client) recordRateLimit(resp *http.Response) {
+	h := http.Header{}
+	for k, vs := range resp.Header {
+		if strings.HasPrefix(k, "X-Ratelimit-") || k == "Retry-After" {
+			h[k] = vs
+		}
+	}
+	if len(h) == 0 {
+		return
+	}
+
+	headerInt := func(key string) int {
+		n, err := strconv.Atoi(h.Get(key))
+		if err != nil {
+			return -1
+		}
+		return n
+	}
+
+	rli := RateLimitInfo{
+		Limit:      headerInt("X-RateLimit-Limit"),
+		Remaining:  headerInt("X-RateLimit-Remaining"),
+		RetryAfter: retryAfter(resp, 0),
+		Header:     h,
+		ReceivedAt: time.Now(),
+	}
+	if reset := headerInt("X-RateLimit-Reset"); reset > 0 {
+		rli.Reset = time.Duration(reset) * time.Second
+	}
+
+	c.rateLimitMu.Lock()
+	c.lastRateLimit = rli
+	c.rateLimitMu.Unlock()
+}
+
+// applyDefaultHeaders adds client-level headers to req. Precedence, highest
+// first: headers already set on the request, the typed Client fields
+// (DeviceID, DeviceName, AsUserID, UserAgent), then DefaultHeaders, then
+// DefaultUserAgent. Authorization is always set by HttpDo and is never taken
+// from DefaultHeaders.
+func (c *Client) applyDefaultHeaders(req *http.Request) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	// device pinning headers, required by some enterprise device trust policies
+	if c.DeviceID != "" && req.Header.Get("Box-Device-ID") == "" {
+		req.Header.Set("Box-Device-ID", c.DeviceID)
+	}
+	if c.DeviceName != "" && req.Header.Get("Box-Device-Name") == "" {
+		req.Header.Set("Box-Device-Name", c.DeviceName)
+	}
+	// impersonation, for service accounts acting on behalf of managed users
+	if c.AsUserID != "" && req.Header.Get("As-User") == "" {
+		req.Header.Set("As-User", c.AsUserID)
+	}
+	if c.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	for k, vs := range c.DefaultHeaders {
+		k = http.CanonicalHeaderKey(k)
+		if k == "Authorization" {
+			continue
+		}
+		if _, ok := req.Header[k]; ok {
+			continue
+		}
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
+	// identify the integration to Box instead of Go's generic User-Agent
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", DefaultUserAgent)
+	}
+}
+
+// Do sends an authenticated request to any Box API endpoint, for endpoints the
+// package doesn't wrap yet. relPath is relative to c.APIBaseURL (e.g.
+// "folders/0/items"), or an absolute http(s) URL such as an upload endpoint.
+// query is merged with any query string already in relPath. body may be nil,
+// an io.Reader or []byte sent as-is, or any other value, which is sent JSON
+// encoded. Responses with a status of 400 or above are returned as an
+// *APIError (with the response body consumed). A request rejected with 401 is
+// retried once with a new access token, unless body is an io.Reader other
+// than a *bytes.Buffer, *bytes.Reader or *strings.Reader, which can't be
+// resent.
+func (c *Client) Do(method, relPath string, query url.Values, body interface{}, opts ...RequestOption) (*http.Response, error) {
+	reqURL, err := c.resolveURL(relPath, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		reqBody     io.Reader
+		contentType string
+	)
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reqBody = b
+	case []byte:
+		reqBody = bytes.NewReader(b)
+	default:
+		js, err := json.Marshal(b)
 		if err != nil {
 			return nil, err
 		}
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", c.lastToken.AccessToken))
-		return http.DefaultClient.Do(req)
+		reqBody = bytes.NewReader(js)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequest(method, reqURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.HttpDo(req, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		ae := newAPIError(resp)
+
+		var ro requestOptions
+		for _, opt := range opts {
+			opt(&ro)
+		}
+		if !ro.skipExternalOwnerCheck {
+			c.markExternallyOwned(ae, req.URL)
+		}
+
+		return nil, ae
 	}
 
 	return resp, nil
 }
+
+// resolveURL joins relPath onto c.APIBaseURL, tolerating leading and trailing
+// slashes on either, leaves absolute URLs as they are, and merges query into
+// any query string relPath already has.
+func (c *Client) resolveURL(relPath string, query url.Values) (string, error) {
+	rawURL := relPath
+	if !(strings.HasPrefix(relPath, "http://") || strings.HasPrefix(relPath, "https://")) {
+		rawURL = strings.TrimRight(c.APIBaseURL, "/") + "/" + strings.TrimLeft(relPath, "/")
+	}
+
+	Url, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	if len(query) > 0 {
+		merged := Url.Query()
+		for k, vs := range query {
+			for _, v := range vs {
+				merged.Add(k, v)
+			}
+		}
+		Url.RawQuery = merged.Encode()
+	}
+
+	return Url.String(), nil
+}
+
+// DoJSON is like Do, but decodes the JSON response body into target (unless
+// target is nil or the response has no body) and closes it.
+func (c *Client) DoJSON(method, relPath string, query url.Values, body interface{}, target interface{}, opts ...RequestOption) error {
+	resp, err := c.Do(method, relPath, query, body, opts...)
+	if err != nil {
+		return err
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	var ro requestOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	if ro.rawResponse != nil {
+		*ro.rawResponse = buf.Bytes()
+	}
+
+	if target == nil || buf.Len() == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), target); err != nil {
+		return fmt.Errorf("Error json.Unmarshal(): %v. Body: %v", err, buf.String())
+	}
+
+	return nil
+}
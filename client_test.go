@@ -0,0 +1,325 @@
+package box
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKeyPEM  []byte
+)
+
+// testFakeBox is a stand-in for the Box API and token endpoint. Tokens are
+// issued as "token-1", "token-2" and so on, unless tokenStatus is set to make
+// the token endpoint fail with that status; requests with a revoked token are
+// rejected with 401.
+type testFakeBox struct {
+	tokensIssued int64
+	tokenStatus  int32
+
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func (fb *testFakeBox) revoke(token string) {
+	fb.mu.Lock()
+	fb.revoked[token] = true
+	fb.mu.Unlock()
+}
+
+func (fb *testFakeBox) isRevoked(token string) bool {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return fb.revoked[token]
+}
+
+// newTestClient returns a Client that talks to a fake Box server, which
+// issues access tokens itself and passes every other authorized request to
+// handler. Call the returned function to shut the server down.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *testFakeBox, func()) {
+	t.Helper()
+
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		testKeyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	})
+	keyFile, err := ioutil.TempFile("", "box-test-key-*.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyFile.Write(testKeyPEM); err != nil {
+		t.Fatal(err)
+	}
+	keyFile.Close()
+
+	fb := &testFakeBox{revoked: map[string]bool{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth2/token" {
+			if status := atomic.LoadInt32(&fb.tokenStatus); status != 0 {
+				w.WriteHeader(int(status))
+				io.WriteString(w, `{"error":"server_error"}`)
+				return
+			}
+			n := atomic.AddInt64(&fb.tokensIssued, 1)
+			json.NewEncoder(w).Encode(OauthTokenResponse{
+				AccessToken:  fmt.Sprintf("token-%d", n),
+				ExpiresIn:    3600,
+				RestrictedTo: []string{"root_readwrite"},
+				TokenType:    "bearer",
+			})
+			return
+		}
+		if fb.isRevoked(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+
+	origTokenURL := APITokenURL
+	APITokenURL = srv.URL + "/oauth2/token"
+
+	c, _ := NewClient("client-id", "client-secret", "enterprise-id", "key-id", keyFile.Name())
+	c.APIBaseURL = srv.URL
+	c.UploadBaseURL = srv.URL
+
+	return c, fb, func() {
+		srv.Close()
+		APITokenURL = origTokenURL
+		os.Remove(keyFile.Name())
+	}
+}
+
+// TestClientConcurrentUse hammers one Client from many goroutines, in rounds.
+// Before each round the current token is revoked, so the round's requests
+// all get a 401 at once and must share a single refresh. Run with -race.
+func TestClientConcurrentUse(t *testing.T) {
+	var requests int64
+	c, fb, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&requests, 1)
+		w.Header().Set("X-RateLimit-Limit", "1000")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", 1000-n%1000))
+		w.Write([]byte(`{"type":"user","id":"1"}`))
+	})
+	defer done()
+
+	const rounds, goroutines, perGoroutine = 3, 20, 10
+
+	for round := 1; round <= rounds; round++ {
+		// Nothing is in flight between rounds, so no request can still hold
+		// an older token; round N starts with token-N current (or, in the
+		// first round, about to be issued)
+		fb.revoke(fmt.Sprintf("token-%d", round))
+
+		var (
+			wg   sync.WaitGroup
+			errs = make(chan error, goroutines*perGoroutine)
+		)
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < perGoroutine; i++ {
+					switch (g + i) % 4 {
+					case 0:
+						var ue UserEntry
+						errs <- c.DoJSON("GET", "users/me", nil, nil, &ue)
+					case 1:
+						resp, err := c.Do("POST", "users/me", nil, []byte(`{}`))
+						if err == nil {
+							resp.Body.Close()
+						}
+						errs <- err
+					case 2:
+						_, err := c.accessToken(false)
+						errs <- err
+						c.LastRateLimit()
+					case 3:
+						_, err := c.replaceAccessToken("no-such-token")
+						errs <- err
+						c.TokenScopes()
+						c.HasScope("root_readwrite")
+					}
+				}
+			}(g)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("round %d: concurrent request failed: %v", round, err)
+			}
+		}
+
+		// One refresh per revoked token, however many requests saw the 401
+		if got, want := atomic.LoadInt64(&fb.tokensIssued), int64(round+1); got != want {
+			t.Fatalf("round %d: tokens issued = %d, want %d", round, got, want)
+		}
+	}
+
+	if rl := c.LastRateLimit(); rl.Limit != 1000 {
+		t.Errorf("LastRateLimit().Limit = %d, want 1000", rl.Limit)
+	}
+}
+
+// onlyReader hides any method but Read, so the request body can't be rewound.
+type onlyReader struct{ io.Reader }
+
+func TestDoUnauthorizedRetry(t *testing.T) {
+	var fb *testFakeBox
+	var bodies []string
+	var mu sync.Mutex
+	c, fb, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		mu.Unlock()
+		// Reject the first token once, as if it had expired early
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "token-1" {
+			fb.revoke(token)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer done()
+
+	// A rewindable body is resent in full with the new token
+	resp, err := c.Do("POST", "comments", nil, bytes.NewReader([]byte("payload")))
+	if err != nil {
+		t.Fatalf("Do with rewindable body: %v", err)
+	}
+	resp.Body.Close()
+	if len(bodies) != 2 || bodies[1] != "payload" {
+		t.Fatalf("bodies received = %q, want the payload twice", bodies)
+	}
+
+	// A body that can't be rewound isn't resent drained; the 401 is returned
+	fb.revoke("token-2")
+	bodies = nil
+	_, err = c.Do("POST", "comments", nil, onlyReader{strings.NewReader("payload")})
+	ae, ok := err.(*APIError)
+	if !ok || ae.Status != http.StatusUnauthorized {
+		t.Fatalf("Do with non-rewindable body: err = %v, want a 401 *APIError", err)
+	}
+	if len(bodies) != 0 {
+		t.Errorf("bodies received = %q, want none for a revoked token", bodies)
+	}
+
+	// The token was replaced all the same, so a retry by the caller works
+	resp, err = c.Do("POST", "comments", nil, onlyReader{strings.NewReader("payload")})
+	if err != nil {
+		t.Fatalf("retried Do: %v", err)
+	}
+	resp.Body.Close()
+	if len(bodies) != 1 || bodies[0] != "payload" {
+		t.Errorf("bodies received = %q, want the payload once", bodies)
+	}
+}
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokenStatus int
+		meStatus    int
+		wantAuth    bool
+		wantStatus  int
+	}{
+		{"ok", 0, http.StatusOK, false, 0},
+		{"token rejected", http.StatusBadRequest, 0, true, http.StatusBadRequest},
+		{"token unauthorized", http.StatusUnauthorized, 0, true, http.StatusUnauthorized},
+		{"token endpoint unavailable", http.StatusServiceUnavailable, 0, false, http.StatusServiceUnavailable},
+		{"token endpoint error", http.StatusInternalServerError, 0, false, http.StatusInternalServerError},
+		{"request unauthorized", 0, http.StatusUnauthorized, true, http.StatusUnauthorized},
+		{"request unavailable", 0, http.StatusServiceUnavailable, false, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, fb, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/users/me" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.WriteHeader(tt.meStatus)
+				io.WriteString(w, `{"type":"user","id":"1"}`)
+			})
+			defer done()
+			fb.tokenStatus = int32(tt.tokenStatus)
+
+			err := c.Ping()
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if errors.Is(err, ErrAuthentication) != tt.wantAuth {
+				t.Errorf("err = %v, want matching ErrAuthentication %v", err, tt.wantAuth)
+			}
+			var ae *APIError
+			if !tt.wantAuth && (!errors.As(err, &ae) || ae.Status != tt.wantStatus) {
+				t.Errorf("err = %v, want an *APIError with status %d", err, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusServiceUnavailable && !errors.Is(err, ErrServiceUnavailable) {
+				t.Errorf("err = %v, want ErrServiceUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		relPath string
+		query   url.Values
+		want    string
+	}{
+		{"plain", "https://api.box.com/2.0", "folders/0/items", nil, "https://api.box.com/2.0/folders/0/items"},
+		{"leading slash", "https://api.box.com/2.0", "/folders/0/items", nil, "https://api.box.com/2.0/folders/0/items"},
+		{"trailing slash on base", "https://api.box.com/2.0/", "folders/0", nil, "https://api.box.com/2.0/folders/0"},
+		{"slashes on both", "https://api.box.com/2.0//", "//folders/0", nil, "https://api.box.com/2.0/folders/0"},
+		{"trailing slash on path kept", "https://api.box.com/2.0", "folders/0/", nil, "https://api.box.com/2.0/folders/0/"},
+		{"absolute https", "https://api.box.com/2.0", "https://upload.box.com/api/2.0/files/content", nil, "https://upload.box.com/api/2.0/files/content"},
+		{"absolute http", "https://api.box.com/2.0", "http://localhost:8080/x", nil, "http://localhost:8080/x"},
+		{"query", "https://api.box.com/2.0", "folders/0/items", url.Values{"limit": {"10"}}, "https://api.box.com/2.0/folders/0/items?limit=10"},
+		{"query merged into relPath query", "https://api.box.com/2.0", "folders/0/items?fields=id", url.Values{"limit": {"10"}}, "https://api.box.com/2.0/folders/0/items?fields=id&limit=10"},
+		{"repeated key merged", "https://api.box.com/2.0", "users?fields=id", url.Values{"fields": {"name"}}, "https://api.box.com/2.0/users?fields=id&fields=name"},
+		{"query on absolute URL", "https://api.box.com/2.0", "https://example.com/info?a=1", url.Values{"b": {"2"}}, "https://example.com/info?a=1&b=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{APIBaseURL: tt.base}
+			got, err := c.resolveURL(tt.relPath, tt.query)
+			if err != nil {
+				t.Fatalf("resolveURL(%q, %v): %v", tt.relPath, tt.query, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveURL(%q, %v) = %q, want %q", tt.relPath, tt.query, got, tt.want)
+			}
+		})
+	}
+}
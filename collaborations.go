@@ -0,0 +1,306 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+var (
+	CollaborationRoleEditor          = "editor"
+	CollaborationRoleViewer          = "viewer"
+	CollaborationRolePreviewer       = "previewer"
+	CollaborationRoleUploader        = "uploader"
+	CollaborationRolePreviewUploader = "previewer uploader"
+	CollaborationRoleViewerUploader  = "viewer uploader"
+	CollaborationRoleCoOwner         = "co-owner"
+	CollaborationRoleOwner           = "owner"
+)
+
+var (
+	CollaborationStatusAccepted = "accepted"
+	CollaborationStatusPending  = "pending"
+	CollaborationStatusRejected = "rejected"
+)
+
+type CollaborationsResponse struct {
+	TotalCount int              `json:"total_count"`
+	Entries    []*Collaboration `json:"entries"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+}
+
+// itemCollaborationsResponse is a page of an item's collaborations; these
+// lists are paged with a marker rather than an offset.
+type itemCollaborationsResponse struct {
+	Entries    []*Collaboration `json:"entries"`
+	Limit      int              `json:"limit"`
+	NextMarker string           `json:"next_marker"`
+}
+
+type Collaboration struct {
+	Type           string        `json:"type,omitempty"`
+	ID             string        `json:"id,omitempty"`
+	Item           *FolderItem   `json:"item,omitempty"`
+	AccessibleBy   *Collaborator `json:"accessible_by,omitempty"`
+	InviteEmail    string        `json:"invite_email,omitempty"`
+	Role           string        `json:"role,omitempty"`
+	Status         string        `json:"status,omitempty"`
+	CreatedBy      *MiniUser     `json:"created_by,omitempty"`
+	CreatedAt      string        `json:"created_at,omitempty"`
+	ModifiedAt     string        `json:"modified_at,omitempty"`
+	ExpiresAt      string        `json:"expires_at,omitempty"`
+	AcknowledgedAt string        `json:"acknowledged_at,omitempty"`
+}
+
+// Collaborator is the user or group a collaboration grants access to.
+type Collaborator struct {
+	Type  string `json:"type,omitempty"` // "user" or "group"
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Login string `json:"login,omitempty"`
+}
+
+type collaborationCreateRequest struct {
+	Item         ItemRef      `json:"item"`
+	AccessibleBy Collaborator `json:"accessible_by"`
+	Role         string       `json:"role"`
+}
+
+// CollaborationsCreate invites a user, by login (email), to a file or folder.
+// itemType is ItemTypeFile or ItemTypeFolder. Set notify to false to suppress
+// Box's invitation email, e.g. when sharing in bulk.
+func (c *Client) CollaborationsCreate(itemType, itemID, login, role string, notify bool) (*Collaboration, error) {
+	if login == "" {
+		return nil, errors.New("No login provided")
+	}
+	return c.collaborationsCreate(itemType, itemID, Collaborator{Type: "user", Login: login}, role, notify)
+}
+
+// CollaborationsCreateForGroup gives a group access to a file or folder.
+func (c *Client) CollaborationsCreateForGroup(itemType, itemID, groupID, role string, notify bool) (*Collaboration, error) {
+	if groupID == "" {
+		return nil, errors.New("No groupID provided")
+	}
+	return c.collaborationsCreate(itemType, itemID, Collaborator{Type: "group", ID: groupID}, role, notify)
+}
+
+func (c *Client) collaborationsCreate(itemType, itemID string, accessibleBy Collaborator, role string, notify bool) (*Collaboration, error) {
+	if !(itemType == ItemTypeFile || itemType == ItemTypeFolder) {
+		return nil, fmt.Errorf("Invalid itemType: %q", itemType)
+	}
+	if itemID == "" {
+		return nil, errors.New("No itemID provided")
+	}
+	if !stringInSlice(role, []string{CollaborationRoleEditor, CollaborationRoleViewer, CollaborationRolePreviewer, CollaborationRoleUploader, CollaborationRolePreviewUploader, CollaborationRoleViewerUploader, CollaborationRoleCoOwner}) {
+		return nil, fmt.Errorf("Invalid collaboration role: %q", role)
+	}
+
+	ccr := collaborationCreateRequest{
+		Item:         ItemRef{Type: itemType, ID: itemID},
+		AccessibleBy: accessibleBy,
+		Role:         role,
+	}
+
+	parameters := url.Values{}
+	parameters.Add("notify", fmt.Sprintf("%t", notify))
+
+	var co Collaboration
+	if err := c.DoJSON("POST", "collaborations", parameters, &ccr, &co); err != nil {
+		return nil, err
+	}
+
+	return &co, nil
+}
+
+// Invitee is one person or group to invite with CollaborationsBulkCreate. Set
+// either Login (a user's email) or GroupID.
+type Invitee struct {
+	Login   string
+	GroupID string
+	Role    string // CollaborationRole* value
+	Notify  bool   // Send Box's invitation email
+}
+
+// key identifies an invitee in CollaborationsBulkCreate results.
+func (i Invitee) key() string {
+	if i.GroupID != "" {
+		return "group:" + i.GroupID
+	}
+	return i.Login
+}
+
+// CollaborationsBulkCreate invites many users and groups to a file or folder,
+// running up to concurrency invitations at once and retrying those Box rate
+// limits. The result is keyed by login, or by "group:<id>" for groups, and
+// holds an entry for every invitee, nil on success.
+func (c *Client) CollaborationsBulkCreate(itemType, itemID string, invitees []Invitee, concurrency int) (map[string]error, error) {
+	if !(itemType == ItemTypeFile || itemType == ItemTypeFolder) {
+		return nil, fmt.Errorf("Invalid itemType: %q", itemType)
+	}
+	if itemID == "" {
+		return nil, errors.New("No itemID provided")
+	}
+	for _, inv := range invitees {
+		if (inv.Login == "") == (inv.GroupID == "") {
+			return nil, fmt.Errorf("Invalid invitee, exactly one of Login and GroupID must be set: %+v", inv)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]error, len(invitees))
+	)
+	forEachConcurrent(len(invitees), concurrency, func(i int) {
+		inv := invitees[i]
+		err := withRateLimitRetry(func() error {
+			var err error
+			if inv.GroupID != "" {
+				_, err = c.CollaborationsCreateForGroup(itemType, itemID, inv.GroupID, inv.Role, inv.Notify)
+			} else {
+				_, err = c.CollaborationsCreate(itemType, itemID, inv.Login, inv.Role, inv.Notify)
+			}
+			return err
+		})
+		mu.Lock()
+		results[inv.key()] = err
+		mu.Unlock()
+	})
+
+	return results, nil
+}
+
+// CollaborationsList returns the collaborations on a file or folder. itemType
+// is ItemTypeFile or ItemTypeFolder.
+func (c *Client) CollaborationsList(itemType, itemID string) ([]*Collaboration, error) {
+	if !(itemType == ItemTypeFile || itemType == ItemTypeFolder) {
+		return nil, fmt.Errorf("Invalid itemType: %q", itemType)
+	}
+	if itemID == "" {
+		return nil, errors.New("No itemID provided")
+	}
+
+	cs := []*Collaboration{}
+	marker := ""
+
+	// Get all collaborations, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("limit", fmt.Sprintf("%d", pageSize(c.PageSizes.Collaborations, defaultCollaborationsPageSize, maxCollaborationsPageSize)))
+		if marker != "" {
+			parameters.Add("marker", marker)
+		}
+
+		var icr itemCollaborationsResponse
+		if err := c.DoJSON("GET", fmt.Sprintf("%ss/%s/collaborations", itemType, itemID), parameters, nil, &icr); err != nil {
+			return cs, err
+		}
+
+		cs = append(cs, icr.Entries...)
+
+		marker = icr.NextMarker
+		if marker == "" || len(icr.Entries) == 0 {
+			break
+		}
+	}
+
+	return cs, nil
+}
+
+// CollaborationsListPendingForMe returns the collaborations offered to the
+// user the client acts as that haven't been accepted or rejected yet.
+func (c *Client) CollaborationsListPendingForMe() ([]*Collaboration, error) {
+	cs := []*Collaboration{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.PendingCollaborations, defaultPendingCollaborationsPageSize, maxPendingCollaborationsPageSize)
+
+	// Get all collaborations, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("status", CollaborationStatusPending)
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var cr CollaborationsResponse
+		if err := c.DoJSON("GET", "collaborations", parameters, nil, &cr); err != nil {
+			return cs, err
+		}
+
+		cs = append(cs, cr.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = cr.Offset + cr.Limit
+
+		if offset >= cr.TotalCount || len(cr.Entries) == 0 {
+			break
+		}
+	}
+
+	return cs, nil
+}
+
+// CollaborationReport lists who has access to what: every collaboration found
+// on the audited items, each with its Item, Role and AccessibleBy set.
+type CollaborationReport struct {
+	Collaborations []*Collaboration
+	ItemsScanned   int
+	Errors         map[ItemRef]error // Items whose collaborations couldn't be listed
+}
+
+// CollaborationsReport gathers the collaborations on the given folders and on
+// every folder beneath them (and every file too, if includeFiles is set). With
+// no folderIDs, it walks the whole tree from the root folder, which covers the
+// enterprise when the client acts as an admin. Listing runs up to concurrency
+// requests at once; keep it low to stay within Box's rate limits on large
+// trees, though rate limited requests are retried. Failing to walk the tree aborts the report, while failures on single
+// items are collected in Errors.
+func (c *Client) CollaborationsReport(folderIDs []string, includeFiles bool, concurrency int) (*CollaborationReport, error) {
+	if len(folderIDs) == 0 {
+		folderIDs = []string{"0"}
+	}
+
+	items := []ItemRef{}
+	for _, folderID := range folderIDs {
+		// The root folder itself can't be collaborated on
+		if folderID != "0" {
+			items = append(items, ItemRef{Type: ItemTypeFolder, ID: folderID})
+		}
+		err := c.FoldersWalk(folderID, func(fi *FolderItem) error {
+			if fi.Type == ItemTypeFolder || (includeFiles && fi.Type == ItemTypeFile) {
+				items = append(items, ItemRef{Type: fi.Type, ID: fi.ID})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		report = &CollaborationReport{
+			Collaborations: []*Collaboration{},
+			ItemsScanned:   len(items),
+			Errors:         map[ItemRef]error{},
+		}
+	)
+	forEachConcurrent(len(items), concurrency, func(i int) {
+		var cs []*Collaboration
+		err := withRateLimitRetry(func() error {
+			var err error
+			cs, err = c.CollaborationsList(items[i].Type, items[i].ID)
+			return err
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			report.Errors[items[i]] = err
+			return
+		}
+		report.Collaborations = append(report.Collaborations, cs...)
+	})
+
+	return report, nil
+}
@@ -0,0 +1,70 @@
+package box
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCollaborationsReport(t *testing.T) {
+	origRateLimit := DefaultRateLimitRetryAfter
+	DefaultRateLimitRetryAfter = time.Millisecond
+	defer func() { DefaultRateLimitRetryAfter = origRateLimit }()
+
+	var (
+		mu    sync.Mutex
+		calls = map[string]int{}
+	)
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/folders/5/items":
+			io.WriteString(w, `{"total_count":2,"offset":0,"limit":1000,"entries":[{"type":"folder","id":"7"},{"type":"file","id":"7"}]}`)
+		case "/folders/7/items":
+			io.WriteString(w, `{"total_count":0,"offset":0,"limit":1000,"entries":[]}`)
+		case "/folders/5/collaborations":
+			io.WriteString(w, `{"entries":[{"type":"collaboration","id":"c5"}]}`)
+		case "/folders/7/collaborations":
+			if n == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			io.WriteString(w, `{"entries":[{"type":"collaboration","id":"c7"}]}`)
+		case "/files/7/collaborations":
+			w.WriteHeader(http.StatusForbidden)
+		case "/files/7":
+			io.WriteString(w, `{"type":"file","id":"7","is_externally_owned":false}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+
+	report, err := c.CollaborationsReport([]string{"5"}, true, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ItemsScanned != 3 {
+		t.Errorf("ItemsScanned = %d, want 3", report.ItemsScanned)
+	}
+	if len(report.Collaborations) != 2 {
+		t.Errorf("got %d collaborations, want the 2 on folders 5 and 7", len(report.Collaborations))
+	}
+
+	// The file and folder share an ID, so only the file may be reported
+	var ae *APIError
+	file := ItemRef{Type: ItemTypeFile, ID: "7"}
+	if len(report.Errors) != 1 || !errors.As(report.Errors[file], &ae) || ae.Status != http.StatusForbidden {
+		t.Errorf("Errors = %v, want only a 403 for %+v", report.Errors, file)
+	}
+	if calls["/folders/7/collaborations"] != 2 {
+		t.Errorf("rate limited folder listed %d times, want 2", calls["/folders/7/collaborations"])
+	}
+}
@@ -0,0 +1,106 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// CollaborationWhitelistExemptTarget exempts a user from the enterprise's
+// collaboration whitelist, so they can collaborate with any domain.
+type CollaborationWhitelistExemptTarget struct {
+	Type       string      `json:"type,omitempty"`
+	ID         string      `json:"id,omitempty"`
+	Enterprise *Enterprise `json:"enterprise,omitempty"`
+	User       *MiniUser   `json:"user,omitempty"`
+	CreatedAt  string      `json:"created_at,omitempty"`
+	ModifiedAt string      `json:"modified_at,omitempty"`
+}
+
+type collaborationWhitelistExemptTargetsResponse struct {
+	Entries    []*CollaborationWhitelistExemptTarget `json:"entries"`
+	Limit      int                                   `json:"limit"`
+	NextMarker string                                `json:"next_marker"`
+}
+
+type collaborationWhitelistExemptTargetRequest struct {
+	User ItemRef `json:"user"`
+}
+
+// CollaborationWhitelistExemptTargetsCreate exempts a user from the
+// collaboration whitelist.
+func (c *Client) CollaborationWhitelistExemptTargetsCreate(userID string) (*CollaborationWhitelistExemptTarget, error) {
+	if userID == "" {
+		return nil, errors.New("No userID provided")
+	}
+
+	req := collaborationWhitelistExemptTargetRequest{
+		User: ItemRef{Type: "user", ID: userID},
+	}
+
+	var et CollaborationWhitelistExemptTarget
+	if err := c.DoJSON("POST", "collaboration_whitelist_exempt_targets", nil, &req, &et); err != nil {
+		return nil, err
+	}
+
+	return &et, nil
+}
+
+// CollaborationWhitelistExemptTargetsList returns every user exemption from
+// the collaboration whitelist.
+func (c *Client) CollaborationWhitelistExemptTargetsList() ([]*CollaborationWhitelistExemptTarget, error) {
+	ets := []*CollaborationWhitelistExemptTarget{}
+	marker := ""
+
+	// Get all exemptions, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("limit", fmt.Sprintf("%d", pageSize(c.PageSizes.CollaborationWhitelistExemptTargets, defaultWhitelistExemptTargetsPageSize, maxWhitelistExemptTargetsPageSize)))
+		if marker != "" {
+			parameters.Add("marker", marker)
+		}
+
+		var r collaborationWhitelistExemptTargetsResponse
+		if err := c.DoJSON("GET", "collaboration_whitelist_exempt_targets", parameters, nil, &r); err != nil {
+			return ets, err
+		}
+
+		ets = append(ets, r.Entries...)
+
+		marker = r.NextMarker
+		if marker == "" || len(r.Entries) == 0 {
+			break
+		}
+	}
+
+	return ets, nil
+}
+
+// CollaborationWhitelistExemptTargetsDelete removes a user's exemption from
+// the collaboration whitelist. Box identifies exemptions by their own ID, so
+// this looks up the user's first.
+func (c *Client) CollaborationWhitelistExemptTargetsDelete(userID string) error {
+	if userID == "" {
+		return errors.New("No userID provided")
+	}
+
+	ets, err := c.CollaborationWhitelistExemptTargetsList()
+	if err != nil {
+		return err
+	}
+
+	for _, et := range ets {
+		if et.User == nil || et.User.ID != userID {
+			continue
+		}
+
+		resp, err := c.Do("DELETE", fmt.Sprintf("collaboration_whitelist_exempt_targets/%s", et.ID), nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	return fmt.Errorf("User %s is not exempt from the collaboration whitelist", userID)
+}
@@ -0,0 +1,150 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+type CommentsResponse struct {
+	TotalCount int        `json:"total_count"`
+	Entries    []*Comment `json:"entries"`
+	Limit      int        `json:"limit"`
+	Offset     int        `json:"offset"`
+}
+
+type Comment struct {
+	Type           string       `json:"type,omitempty"`
+	ID             string       `json:"id,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	TaggedMessage  string       `json:"tagged_message,omitempty"`
+	IsReplyComment bool         `json:"is_reply_comment,omitempty"`
+	Item           *CommentItem `json:"item,omitempty"`
+	CreatedBy      *MiniUser    `json:"created_by,omitempty"`
+	CreatedAt      string       `json:"created_at,omitempty"`
+	ModifiedAt     string       `json:"modified_at,omitempty"`
+}
+
+// CommentItem is what a comment was placed on: the file for top-level
+// comments, the parent comment for replies.
+type CommentItem struct {
+	Type string `json:"type"` // "file" or "comment"
+	ID   string `json:"id"`
+}
+
+// ParentID returns the ID of the comment this one replies to, or "" for a
+// top-level comment.
+func (cm *Comment) ParentID() string {
+	if cm.Item == nil || cm.Item.Type != "comment" {
+		return ""
+	}
+	return cm.Item.ID
+}
+
+type commentCreateRequest struct {
+	Message string      `json:"message"`
+	Item    CommentItem `json:"item"`
+}
+
+// CommentsCreate adds a comment to a file. If parentCommentID is set, the
+// comment is posted as a reply to that comment instead (fileID may then be
+// empty).
+func (c *Client) CommentsCreate(fileID, message, parentCommentID string) (*Comment, error) {
+	if message == "" {
+		return nil, errors.New("No message provided")
+	}
+
+	item := CommentItem{Type: ItemTypeFile, ID: fileID}
+	if parentCommentID != "" {
+		item = CommentItem{Type: "comment", ID: parentCommentID}
+	} else if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+
+	ccr := commentCreateRequest{
+		Message: message,
+		Item:    item,
+	}
+
+	var cm Comment
+	if err := c.DoJSON("POST", "comments", nil, &ccr, &cm); err != nil {
+		return nil, err
+	}
+
+	return &cm, nil
+}
+
+type commentUpdateRequest struct {
+	Message string `json:"message"`
+}
+
+// CommentsUpdate replaces the message of a comment, e.g. to fix a typo.
+func (c *Client) CommentsUpdate(commentID, message string) (*Comment, error) {
+	if commentID == "" {
+		return nil, errors.New("No commentID provided")
+	}
+	if message == "" {
+		return nil, errors.New("No message provided")
+	}
+
+	cur := commentUpdateRequest{Message: message}
+
+	var cm Comment
+	if err := c.DoJSON("PUT", fmt.Sprintf("comments/%s", commentID), nil, &cur, &cm); err != nil {
+		return nil, err
+	}
+
+	return &cm, nil
+}
+
+// CommentsDelete permanently deletes a comment.
+func (c *Client) CommentsDelete(commentID string) error {
+	if commentID == "" {
+		return errors.New("No commentID provided")
+	}
+
+	resp, err := c.Do("DELETE", fmt.Sprintf("comments/%s", commentID), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// FileListComments returns all comments on a file, including replies. Use
+// Comment.ParentID to reconstruct threads.
+func (c *Client) FileListComments(fileID string) ([]*Comment, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+
+	cms := []*Comment{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.Comments, defaultCommentsPageSize, maxCommentsPageSize)
+
+	// Get all comments, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("fields", "type,id,message,tagged_message,is_reply_comment,item,created_by,created_at,modified_at")
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var cr CommentsResponse
+		if err := c.DoJSON("GET", fmt.Sprintf("files/%s/comments", fileID), parameters, nil, &cr); err != nil {
+			return cms, err
+		}
+
+		cms = append(cms, cr.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = cr.Offset + cr.Limit
+
+		if offset >= cr.TotalCount || len(cr.Entries) == 0 {
+			break
+		}
+	}
+
+	return cms, nil
+}
@@ -0,0 +1,155 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Files at least this large are uploaded by UploadDirectory through upload
+// sessions rather than in a single request. Box requires at least 20MB.
+var ChunkedUploadThreshold int64 = 50 * 1024 * 1024
+
+// UploadResult summarizes an UploadDirectory run. Every regular file found
+// ends up in either Files or Errors, keyed by its local path.
+type UploadResult struct {
+	FoldersCreated int
+	Files          map[string]*FileEntry
+	Errors         map[string]error
+}
+
+// UploadDirectory uploads the contents of localDir, including subdirectories,
+// into destFolderID, running up to concurrency file uploads at once. Folders
+// that already exist in Box are reused, and files whose name is already taken
+// are uploaded as a new version of the existing file. Anything but regular
+// files and directories (e.g. symlinks) is skipped.
+//
+// All folders are created before any file is uploaded; failing to create one
+// stops the run, while failed file uploads are collected in the result.
+func (c *Client) UploadDirectory(localDir, destFolderID string, concurrency int) (*UploadResult, error) {
+	if destFolderID == "" {
+		return nil, errors.New("No destFolderID provided")
+	}
+
+	result := &UploadResult{
+		Files:  map[string]*FileEntry{},
+		Errors: map[string]error{},
+	}
+
+	// filepath.Walk visits directories before their contents, so the Box ID
+	// of a file's or folder's parent is always known by the time it's reached
+	folderIDs := map[string]string{}
+	type localFile struct {
+		path     string
+		size     int64
+		folderID string
+	}
+	files := []localFile{}
+
+	err := filepath.Walk(localDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == localDir {
+			if !fi.IsDir() {
+				return fmt.Errorf("Not a directory: %s", localDir)
+			}
+			folderIDs[path] = destFolderID
+			return nil
+		}
+
+		parentID := folderIDs[filepath.Dir(path)]
+		switch {
+		case fi.IsDir():
+			id, created, err := c.uploadDirectoryFolder(fi.Name(), parentID)
+			if err != nil {
+				return fmt.Errorf("Creating folder for %s: %w", path, err)
+			}
+			if created {
+				result.FoldersCreated++
+			}
+			folderIDs[path] = id
+		case fi.Mode().IsRegular():
+			files = append(files, localFile{path: path, size: fi.Size(), folderID: parentID})
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	var mu sync.Mutex
+	forEachConcurrent(len(files), concurrency, func(i int) {
+		fe, err := c.uploadDirectoryFile(files[i].path, files[i].size, files[i].folderID)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Errors[files[i].path] = err
+			return
+		}
+		result.Files[files[i].path] = fe
+	})
+
+	return result, nil
+}
+
+// uploadDirectoryFolder returns the ID of the folder called name in parentID,
+// creating it unless it already exists.
+func (c *Client) uploadDirectoryFolder(name, parentID string) (string, bool, error) {
+	fe, err := c.FoldersCreate(name, parentID)
+	if err == nil {
+		return fe.ID, true, nil
+	}
+
+	if existing := conflictingItem(err); existing != nil && existing.Type == ItemTypeFolder {
+		return existing.ID, false, nil
+	}
+	return "", false, err
+}
+
+// uploadDirectoryFile uploads a local file into folderID, as a new version of
+// the existing file if the name is taken. A failed chunked upload's session
+// is aborted.
+func (c *Client) uploadDirectoryFile(path string, size int64, folderID string) (*FileEntry, error) {
+	var (
+		fur *FileUploadResponse
+		err error
+	)
+
+	if size >= ChunkedUploadThreshold {
+		var sessionID string
+		fur, sessionID, err = c.FileUploadChunkedFromPath(path, folderID, "")
+		if existing := conflictingItem(err); existing != nil && existing.Type == ItemTypeFile {
+			fur, sessionID, err = c.FileUploadVersionChunkedFromPath(path, existing.ID, "")
+		}
+
+		// UploadDirectory has no way to resume the session a transient
+		// failure leaves open, so don't leave it holding on to storage
+		if err != nil && sessionID != "" {
+			if abortErr := c.AbortUploadSession(sessionID); abortErr != nil {
+				err = fmt.Errorf("%w (aborting upload session %s also failed: %v)", err, sessionID, abortErr)
+			}
+		}
+	} else {
+		var fure *FileUploadResponseError
+		fur, fure, err = c.FileUploadFromPath(path, folderID)
+		if fure != nil && fure.Status == http.StatusConflict && fure.ContextInfo.Conflicts.Type == ItemTypeFile {
+			fur, fure, err = c.FileUploadVersionFromPath(path, fure.ContextInfo.Conflicts.ID)
+		}
+		if err == nil && fure != nil {
+			err = fure
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fur.Entries) == 0 {
+		return nil, errors.New("Box returned no file entry")
+	}
+	return fur.Entries[0], nil
+}
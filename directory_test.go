@@ -0,0 +1,56 @@
+package box
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadDirectoryAbortsFailedSession(t *testing.T) {
+	origThreshold := ChunkedUploadThreshold
+	ChunkedUploadThreshold = 1
+	defer func() { ChunkedUploadThreshold = origThreshold }()
+
+	dir, err := ioutil.TempDir("", "box-test-upload-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "big.bin")
+	if err := ioutil.WriteFile(path, []byte("0123456789"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	aborted := false
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /files/upload_sessions":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"type":"upload_session","id":"s1","part_size":4,"total_parts":3}`)
+		case "PUT /files/upload_sessions/s1":
+			// A server error leaves the session open for resuming
+			w.WriteHeader(http.StatusInternalServerError)
+		case "DELETE /files/upload_sessions/s1":
+			aborted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+
+	result, err := c.UploadDirectory(dir, "0", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Errors[path] == nil {
+		t.Errorf("Errors = %v, want an error for %s", result.Errors, path)
+	}
+	if !aborted {
+		t.Error("upload session was left open")
+	}
+}
@@ -0,0 +1,209 @@
+package box
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Sentinel errors an *APIError can be matched against with errors.Is.
+var (
+	// ErrCrossEnterprise indicates the item lives in, or is governed by the
+	// policies of, a different enterprise than the authenticated user's, e.g.
+	// externally owned files reached through an external collaboration. It
+	// matches 403 errors on items Box reports as externally owned (see
+	// APIError.ExternallyOwned), and 403s with one of the
+	// CrossEnterpriseErrorCodes.
+	ErrCrossEnterprise = errors.New("Item belongs to another enterprise")
+
+	// ErrSharedLinkPasswordRequired indicates a shared link is password
+	// protected and no password, or the wrong one, was given.
+	ErrSharedLinkPasswordRequired = errors.New("Shared link password missing or incorrect")
+
+	// ErrFolderNotEmpty indicates a folder couldn't be deleted without the
+	// recursive option because it still has items in it.
+	ErrFolderNotEmpty = errors.New("Folder is not empty")
+
+	// ErrServiceUnavailable indicates Box is temporarily unavailable (503),
+	// e.g. during a maintenance window. The APIError's RetryAfter says how
+	// long to wait before trying again.
+	ErrServiceUnavailable = errors.New("Box service unavailable")
+
+	// ErrStorageLimitExceeded indicates an upload was rejected because the
+	// user is over their storage quota.
+	ErrStorageLimitExceeded = errors.New("Storage limit exceeded")
+
+	// ErrNameConflict indicates an item couldn't be created, uploaded or
+	// moved because its name is already taken in the target folder. The
+	// existing item's ID is available from ConflictingItemID.
+	ErrNameConflict = errors.New("Item name already in use")
+)
+
+// Box error codes of 403 responses that mean the item belongs to another
+// enterprise, matched by ErrCrossEnterprise in addition to 403s on externally
+// owned items. Generic policy denials such as "forbidden_by_policy" (shield,
+// information barriers, classification) are deliberately not included, as
+// most aren't about enterprises at all. Add the codes your enterprise's
+// external collaboration restrictions produce.
+var CrossEnterpriseErrorCodes = []string{}
+
+// Default wait suggested by APIError.RetryAfter when Box doesn't send a
+// Retry-After header. Maintenance outlasts rate limiting, so 503s wait longer.
+var (
+	DefaultRateLimitRetryAfter          = 1 * time.Second
+	DefaultServiceUnavailableRetryAfter = 1 * time.Minute
+)
+
+// APIError is the error object Box returns in the body of failed API requests.
+type APIError struct {
+	Type        string          `json:"type"`
+	Status      int             `json:"status"`
+	Code        string          `json:"code"`
+	ContextInfo json.RawMessage `json:"context_info,omitempty"`
+	HelpURL     string          `json:"help_url"`
+	Message     string          `json:"message"`
+	RequestID   string          `json:"request_id"`
+	Body        string          `json:"-"` // Raw response body, for errors Box didn't return as JSON
+	RetryAfter  time.Duration   `json:"-"` // How long to wait before retrying; only set for 429 and 503 responses
+
+	// ExternallyOwned is set on 403s from Do and DoJSON when the request was
+	// about a file or folder that Box reports as owned by another enterprise.
+	ExternallyOwned bool `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" && e.Message == "" {
+		return fmt.Sprintf("Unexpected status code while executing API request: %d. HTTP Response body: [%s]", e.Status, e.Body)
+	}
+	return fmt.Sprintf("Box API error: %d %s: %s (request id: %s)", e.Status, e.Code, e.Message, e.RequestID)
+}
+
+// Is lets errors.Is match an APIError against the package's sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrCrossEnterprise:
+		return e.Status == http.StatusForbidden && (e.ExternallyOwned || stringInSlice(e.Code, CrossEnterpriseErrorCodes))
+	case ErrSharedLinkPasswordRequired:
+		return e.Code == "incorrect_shared_item_password"
+	case ErrFolderNotEmpty:
+		return e.Code == "folder_not_empty"
+	case ErrServiceUnavailable:
+		return e.Status == http.StatusServiceUnavailable
+	case ErrStorageLimitExceeded:
+		return e.Code == "storage_limit_exceeded"
+	case ErrNameConflict:
+		return e.Status == http.StatusConflict && e.Code == "item_name_in_use"
+	}
+	return false
+}
+
+// ConflictingItemID returns the ID of the existing item a name conflict error
+// (see ErrNameConflict) reports, e.g. to upload a new version of it instead,
+// or "" if err doesn't name one. It accepts the *FileUploadResponseError of
+// the upload methods as well as an *APIError, wrapped or not.
+func ConflictingItemID(err error) string {
+	var fure *FileUploadResponseError
+	if errors.As(err, &fure) {
+		return fure.ContextInfo.Conflicts.ID
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		if item := conflictingItem(ae); item != nil {
+			return item.ID
+		}
+	}
+	return ""
+}
+
+// Error makes a failed upload usable as an error, so it can be matched against
+// the same sentinel errors as an *APIError, e.g. ErrStorageLimitExceeded.
+func (e *FileUploadResponseError) Error() string {
+	return e.apiError().Error()
+}
+
+func (e *FileUploadResponseError) Is(target error) bool {
+	return e.apiError().Is(target)
+}
+
+func (e *FileUploadResponseError) apiError() *APIError {
+	return &APIError{
+		Type:      e.Type,
+		Status:    e.Status,
+		Code:      e.Code,
+		HelpURL:   e.HelpURL,
+		Message:   e.Message,
+		RequestID: e.RequestID,
+	}
+}
+
+// tokenError is a failed request for a new access token. It wraps the
+// APIError built from the token endpoint's response, so a 503 there still
+// matches ErrServiceUnavailable.
+type tokenError struct {
+	ae *APIError
+}
+
+func (e *tokenError) Error() string {
+	return fmt.Sprintf("Unexpected status code while retrieving new Oauth2 access token: [%v]. HTTP Response body: [%s]", e.ae.Status, e.ae.Body)
+}
+
+func (e *tokenError) Unwrap() error {
+	return e.ae
+}
+
+// itemURLPattern finds the file or folder a request URL is about, e.g.
+// ".../files/123/content" or ".../folders/456".
+var itemURLPattern = regexp.MustCompile(`/(files|folders)/(\d+)(/|$)`)
+
+// markExternallyOwned sets ae.ExternallyOwned when ae is a 403 for a request
+// about a file or folder that Box reports as externally owned. The lookup's
+// own errors are ignored: the item may well be unreadable too.
+func (c *Client) markExternallyOwned(ae *APIError, reqURL *url.URL) {
+	if ae.Status != http.StatusForbidden || reqURL == nil {
+		return
+	}
+	m := itemURLPattern.FindStringSubmatch(reqURL.Path)
+	if m == nil {
+		return
+	}
+
+	var item struct {
+		IsExternallyOwned bool `json:"is_externally_owned"`
+	}
+	err := c.DoJSON("GET", fmt.Sprintf("%s/%s", m[1], m[2]), url.Values{"fields": {"is_externally_owned"}}, nil, &item, skipExternalOwnerCheck())
+	if err == nil && item.IsExternallyOwned {
+		ae.ExternallyOwned = true
+	}
+}
+
+// newAPIError builds an APIError from a failed response, consuming and
+// closing its body.
+func newAPIError(resp *http.Response) *APIError {
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	var ae APIError
+	if err := json.Unmarshal(buf.Bytes(), &ae); err != nil {
+		ae = APIError{}
+	}
+	// Trust the actual response code over the body
+	ae.Status = resp.StatusCode
+	ae.Body = strings.TrimSpace(buf.String())
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		ae.RetryAfter = retryAfter(resp, DefaultRateLimitRetryAfter)
+	case http.StatusServiceUnavailable:
+		ae.RetryAfter = retryAfter(resp, DefaultServiceUnavailableRetryAfter)
+	}
+
+	return &ae
+}
@@ -0,0 +1,55 @@
+package box
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestErrCrossEnterprise(t *testing.T) {
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/5", "/folders/6":
+			// Item info: file 5 is owned by another enterprise, folder 6 isn't
+			if r.URL.Query().Get("fields") != "is_externally_owned" {
+				t.Errorf("unexpected item request %s", r.URL)
+			}
+			if r.URL.Path == "/files/5" {
+				w.Write([]byte(`{"type":"file","id":"5","is_externally_owned":true}`))
+			} else {
+				w.Write([]byte(`{"type":"folder","id":"6","is_externally_owned":false}`))
+			}
+		case "/files/5/versions", "/folders/6/items":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"type":"error","status":403,"code":"access_denied_insufficient_permissions","message":"Access denied - insufficient permission"}`))
+		case "/files/7/versions":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"type":"error","status":403,"code":"forbidden_by_policy","message":"Access denied - Blocked by Shield"}`))
+		case "/files/7":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"type":"error","status":403,"code":"forbidden_by_policy"}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+
+	tests := []struct {
+		relPath string
+		want    bool
+	}{
+		{"files/5/versions", true},  // externally owned
+		{"folders/6/items", false},  // owned by our enterprise
+		{"files/7/versions", false}, // policy denial, item not even readable
+	}
+	for _, tt := range tests {
+		err := c.DoJSON("GET", tt.relPath, nil, nil, nil)
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.relPath)
+		}
+		if got := errors.Is(err, ErrCrossEnterprise); got != tt.want {
+			t.Errorf("%s: errors.Is(%v, ErrCrossEnterprise) = %v, want %v", tt.relPath, err, got, tt.want)
+		}
+	}
+}
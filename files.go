@@ -2,18 +2,35 @@ package box
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+var ErrVersionDeletionDisabled = errors.New("File version deletion is not permitted for this file or enterprise")
+
+// ErrIncompleteDownload is returned when a download ends before all of the
+// advertised Content-Length has been received, e.g. on a dropped connection.
+var ErrIncompleteDownload = errors.New("Download ended before the full content was received")
+
+// How long FileDownload keeps retrying while Box reports (202) that the file
+// is not yet available for download
+var FileDownloadRetryTimeout = 2 * time.Minute
+
 type FileUploadRequest struct {
 	Name   string                  `json:"name,omitempty"`
 	Parent FileUploadRequestParent `json:"parent,omitempty"`
@@ -23,66 +40,82 @@ type FileUploadRequestParent struct {
 }
 
 type FileUploadResponse struct {
-	Status     int `json:"status"`
-	TotalCount int `json:"total_count"`
-	Entries    []struct {
-		Type        string `json:"type"`
-		ID          string `json:"id"`
-		FileVersion struct {
-			Type string `json:"type"`
-			ID   string `json:"id"`
-			Sha1 string `json:"sha1"`
-		} `json:"file_version"`
-		SequenceID     string `json:"sequence_id"`
-		Etag           string `json:"etag"`
-		Sha1           string `json:"sha1"`
-		Name           string `json:"name"`
-		Description    string `json:"description"`
-		Size           int    `json:"size"`
-		PathCollection struct {
-			TotalCount int `json:"total_count"`
-			Entries    []struct {
-				Type       string      `json:"type"`
-				ID         string      `json:"id"`
-				SequenceID interface{} `json:"sequence_id"`
-				Etag       interface{} `json:"etag"`
-				Name       string      `json:"name"`
-			} `json:"entries"`
-		} `json:"path_collection"`
-		CreatedAt         string      `json:"created_at"`
-		ModifiedAt        string      `json:"modified_at"`
-		TrashedAt         interface{} `json:"trashed_at"`
-		PurgedAt          interface{} `json:"purged_at"`
-		ContentCreatedAt  string      `json:"content_created_at"`
-		ContentModifiedAt string      `json:"content_modified_at"`
-		CreatedBy         struct {
-			Type  string `json:"type"`
-			ID    string `json:"id"`
-			Name  string `json:"name"`
-			Login string `json:"login"`
-		} `json:"created_by"`
-		ModifiedBy struct {
-			Type  string `json:"type"`
-			ID    string `json:"id"`
-			Name  string `json:"name"`
-			Login string `json:"login"`
-		} `json:"modified_by"`
-		OwnedBy struct {
-			Type  string `json:"type"`
-			ID    string `json:"id"`
-			Name  string `json:"name"`
-			Login string `json:"login"`
-		} `json:"owned_by"`
-		SharedLink interface{} `json:"shared_link"`
-		Parent     struct {
-			Type       string `json:"type"`
-			ID         string `json:"id"`
-			SequenceID string `json:"sequence_id"`
-			Etag       string `json:"etag"`
-			Name       string `json:"name"`
-		} `json:"parent"`
-		ItemStatus string `json:"item_status"`
-	} `json:"entries"`
+	Status     int          `json:"status"`
+	TotalCount int          `json:"total_count"`
+	Entries    []*FileEntry `json:"entries"`
+}
+
+type FileEntry struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	FileVersion struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Sha1 string `json:"sha1"`
+	} `json:"file_version"`
+	SequenceID     string `json:"sequence_id"`
+	Etag           string `json:"etag"`
+	Sha1           string `json:"sha1"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Size           int64  `json:"size"`
+	PathCollection struct {
+		TotalCount int `json:"total_count"`
+		Entries    []struct {
+			Type       string      `json:"type"`
+			ID         string      `json:"id"`
+			SequenceID interface{} `json:"sequence_id"`
+			Etag       interface{} `json:"etag"`
+			Name       string      `json:"name"`
+		} `json:"entries"`
+	} `json:"path_collection"`
+	CreatedAt         string      `json:"created_at"`
+	ModifiedAt        string      `json:"modified_at"`
+	TrashedAt         interface{} `json:"trashed_at"`
+	PurgedAt          interface{} `json:"purged_at"`
+	ContentCreatedAt  string      `json:"content_created_at"`
+	ContentModifiedAt string      `json:"content_modified_at"`
+	CreatedBy         struct {
+		Type  string `json:"type"`
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Login string `json:"login"`
+	} `json:"created_by"`
+	ModifiedBy struct {
+		Type  string `json:"type"`
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Login string `json:"login"`
+	} `json:"modified_by"`
+	OwnedBy struct {
+		Type  string `json:"type"`
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Login string `json:"login"`
+	} `json:"owned_by"`
+	SharedLink *SharedLink `json:"shared_link"`
+	Parent     struct {
+		Type       string `json:"type"`
+		ID         string `json:"id"`
+		SequenceID string `json:"sequence_id"`
+		Etag       string `json:"etag"`
+		Name       string `json:"name"`
+	} `json:"parent"`
+	ItemStatus        string `json:"item_status"`
+	IsExternallyOwned bool   `json:"is_externally_owned"`
+	Extension         string `json:"extension"`
+	IsPackage         bool   `json:"is_package"` // Mac package formats such as .key, .pages and .numbers
+
+	WatermarkInfo *WatermarkInfo `json:"watermark_info"` // Only returned when requested in fields
+}
+
+// FileRef is a lightweight view of a file, for when decoding a full FileEntry
+// would be wasted work.
+type FileRef struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Sha1 string `json:"sha1"`
 }
 
 type FileUploadResponseError struct {
@@ -109,7 +142,68 @@ type FileUploadResponseError struct {
 	RequestID string `json:"request_id"`
 }
 
+// What an upload does when its name is taken, see UploadOptions.OnNameConflict
+var (
+	UploadConflictError      = "error"       // Fail, as Box does
+	UploadConflictRename     = "rename"      // Upload as a new file with a numbered name, e.g. "a (1).txt"
+	UploadConflictNewVersion = "new_version" // Upload as a new version of the existing file
+)
+
+// UploadOptions adjusts the behavior of the *WithOptions upload methods. A nil
+// *UploadOptions is equivalent to the zero value.
+type UploadOptions struct {
+	// SHA1 is the hex SHA1 digest of the content, if already known. Box uses it
+	// (sent as the Content-MD5 header) to verify the upload. When empty it's
+	// computed while the request body is built.
+	SHA1 string
+
+	// IdempotentByName makes a new-file upload first check whether the target
+	// folder already holds a file with the same name, size and SHA1. If so,
+	// that file is returned (with Status 200) instead of uploading again, so
+	// retried uploads don't create duplicates. Content from a reader is
+	// buffered in memory to compute its digest.
+	IdempotentByName bool
+
+	// Name is the file name to store in Box, instead of the local file's
+	// name, for uploads from a path. Version uploads keep the Box file's
+	// current name unless Name is set, in which case the file is renamed.
+	Name string
+
+	// OnNameConflict is what a new-file upload does when the target folder
+	// already has an item of the same name: one of the UploadConflict* values,
+	// UploadConflictError by default.
+	OnNameConflict string
+
+	// SkipIfUnchanged makes a version upload first compare the content's
+	// SHA1 with the file's current version in Box. If they match (and Name
+	// doesn't ask for a rename), nothing is uploaded and the existing file is
+	// returned with Status 200.
+	SkipIfUnchanged bool
+
+	// OnSuccess is called with the uploaded file once Box has accepted the
+	// upload, e.g. to start a virus scan or a workflow. It isn't called when
+	// IdempotentByName or SkipIfUnchanged made the upload unnecessary.
+	OnSuccess func(*FileEntry) error
+
+	// OnSuccessWebhook, if set, is a URL the uploaded file is POSTed to as
+	// JSON once Box has accepted the upload, after OnSuccess. It is sent
+	// without Box credentials.
+	OnSuccessWebhook string
+}
+
+// FileUploadFromPath uploads a local file into a Box folder.
+//
+// When migrating content, upload with a Client whose AsUserID is the original
+// owner: the upload (and any preflight checks UploadOptions trigger) is then
+// made as that user, so Box records them as the file's owner and creator
+// rather than the service account. The uploading user needs access to the
+// target folder, and the app needs the "Perform actions as users" setting.
+// Check OwnedBy on the returned entry to confirm.
 func (c *Client) FileUploadFromPath(localFilepath, boxFolderID string) (*FileUploadResponse, *FileUploadResponseError, error) {
+	return c.FileUploadFromPathWithOptions(localFilepath, boxFolderID, nil)
+}
+
+func (c *Client) FileUploadFromPathWithOptions(localFilepath, boxFolderID string, opts *UploadOptions) (*FileUploadResponse, *FileUploadResponseError, error) {
 	// Validation
 	if localFilepath == "" {
 		return nil, nil, errors.New("No localFilepath provided")
@@ -118,13 +212,6 @@ func (c *Client) FileUploadFromPath(localFilepath, boxFolderID string) (*FileUpl
 		return nil, nil, errors.New("No boxFolderID provided")
 	}
 
-	// filename := filepath.Base(localFilepath)
-
-	Url, err := url.Parse(fmt.Sprintf("%s/%s", c.UploadBaseURL, "files/content"))
-	if err != nil {
-		return nil, nil, err
-	}
-
 	// Read upload file
 	file, err := os.Open(localFilepath)
 	if err != nil {
@@ -132,91 +219,210 @@ func (c *Client) FileUploadFromPath(localFilepath, boxFolderID string) (*FileUpl
 	}
 	defer file.Close()
 
-	fileContents, err := ioutil.ReadAll(file)
+	fi, err := file.Stat()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	fi, err := file.Stat()
-	if err != nil {
-		return nil, nil, err
+	name := fi.Name()
+	if opts != nil && opts.Name != "" {
+		name = opts.Name
 	}
 
-	var (
-		body   = &bytes.Buffer{}
-		writer = multipart.NewWriter(body)
-	)
+	return c.FileUploadFromReaderWithOptions(file, name, boxFolderID, opts)
+}
+
+// FileUploadFromReader uploads the contents of r as a new file named name in
+// the given Box folder. Zero-length content is supported and creates an empty
+// file in Box.
+func (c *Client) FileUploadFromReader(r io.Reader, name, boxFolderID string) (*FileUploadResponse, *FileUploadResponseError, error) {
+	return c.FileUploadFromReaderWithOptions(r, name, boxFolderID, nil)
+}
 
-	// write the file
-	part, err := writer.CreateFormFile("file", fi.Name())
+func (c *Client) FileUploadFromReaderWithOptions(r io.Reader, name, boxFolderID string, opts *UploadOptions) (*FileUploadResponse, *FileUploadResponseError, error) {
+	// Validation
+	if r == nil {
+		return nil, nil, errors.New("No reader provided")
+	}
+	if err := ValidateItemName(name); err != nil {
+		return nil, nil, err
+	}
+	if boxFolderID == "" {
+		return nil, nil, errors.New("No boxFolderID provided")
+	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/%s", c.UploadBaseURL, "files/content"))
 	if err != nil {
 		return nil, nil, err
 	}
-	part.Write(fileContents)
 
-	// write the other form fields we need
 	fureq := FileUploadRequest{
-		Name: fi.Name(),
+		Name: name,
 		Parent: FileUploadRequestParent{
 			ID: boxFolderID,
 		},
 	}
-	js, err := json.Marshal(&fureq)
-	if err != nil {
-		return nil, nil, err
+
+	var size int64
+	if opts.IdempotentByName {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		digest := opts.SHA1
+		if digest == "" {
+			sum := sha1.Sum(content)
+			digest = hex.EncodeToString(sum[:])
+		}
+
+		existing, err := c.findIdenticalFile(name, boxFolderID, int64(len(content)), digest)
+		if err != nil {
+			return nil, nil, err
+		}
+		if existing != nil {
+			return &FileUploadResponse{
+				Status:     http.StatusOK,
+				TotalCount: 1,
+				Entries:    []*FileEntry{existing},
+			}, nil, nil
+		}
+
+		// Reuse the digest rather than hashing again
+		withDigest := *opts
+		withDigest.SHA1 = digest
+		opts = &withDigest
+		r = bytes.NewReader(content)
+		size = int64(len(content))
 	}
 
-	err = writer.WriteField("attributes", string(js))
-	if err != nil {
-		return nil, nil, err
+	// Settle name conflicts before uploading, as the content can't be re-read
+	// for a second attempt
+	switch opts.OnNameConflict {
+	case "", UploadConflictError:
+	case UploadConflictRename:
+		newName, err := c.uploadFreeName(name, boxFolderID, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		fureq.Name = newName
+		name = newName
+	case UploadConflictNewVersion:
+		conflict, err := c.uploadPreflight(name, boxFolderID, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		if conflict != nil {
+			if conflict.Conflicts.Type != ItemTypeFile {
+				return nil, nil, fmt.Errorf("Can't upload %q as a new version: the name is taken by a %s", name, conflict.Conflicts.Type)
+			}
+			Url, err = url.Parse(fmt.Sprintf("%s/files/%s/content", c.UploadBaseURL, conflict.Conflicts.ID))
+			if err != nil {
+				return nil, nil, err
+			}
+			// A version upload keeps the existing file's name and folder
+			fureq = FileUploadRequest{}
+		}
+	default:
+		return nil, nil, fmt.Errorf("Invalid OnNameConflict: %q", opts.OnNameConflict)
 	}
 
-	err = writer.Close()
-	if err != nil {
-		return nil, nil, err
+	return c.fileUpload(Url.String(), &fureq, name, r, opts)
+}
+
+// uploadPreflight asks Box whether a file of size bytes could be uploaded as
+// name into folderID. It returns the conflicting item if the name is taken,
+// or nil if it's free.
+func (c *Client) uploadPreflight(name, folderID string, size int64) (*uploadPreflightConflict, error) {
+	upr := uploadPreflightRequest{
+		Name: name,
+		Size: size,
+		Parent: FileUploadRequestParent{
+			ID: folderID,
+		},
 	}
 
-	req, err := http.NewRequest("POST", Url.String(), body)
-	if err != nil {
-		return nil, nil, err
+	err := c.DoJSON("OPTIONS", "files/content", nil, &upr, nil)
+	if err == nil {
+		return nil, nil
 	}
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-	if err != nil {
-		return nil, nil, err
+	ae, ok := err.(*APIError)
+	if !ok || ae.Status != http.StatusConflict {
+		return nil, err
 	}
 
-	// make request with valid access token
-	resp, err := c.HttpDo(req)
-	if err != nil {
-		return nil, nil, err
+	var upc uploadPreflightConflict
+	if err := json.Unmarshal(ae.ContextInfo, &upc); err != nil {
+		return nil, fmt.Errorf("Error json.Unmarshal(&upc): %v. Body: %v", err, ae.Body)
 	}
 
-	// Read the response body
-	buf := new(bytes.Buffer)
-	io.Copy(buf, resp.Body)
-	resp.Body.Close()
-	// fmt.Println(buf.String())
+	return &upc, nil
+}
 
-	if !(resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated) {
-		var fure FileUploadResponseError
-		if err := json.Unmarshal(buf.Bytes(), &fure); err != nil {
-			return nil, nil, fmt.Errorf("Error json.Unmarshal(&fure): %v. Body: %v", err, buf.String())
+// uploadFreeName returns name, or the first numbered alternative to it, that
+// isn't taken in folderID.
+func (c *Client) uploadFreeName(name, folderID string, size int64) (string, error) {
+	candidate := name
+	for n := 1; ; n++ {
+		conflict, err := c.uploadPreflight(candidate, folderID, size)
+		if err != nil {
+			return "", err
 		}
-		return nil, &fure, nil
+		if conflict == nil {
+			return candidate, nil
+		}
+		if n > MaxConflictRenames {
+			return "", fmt.Errorf("No free name for %q after %d attempts", name, MaxConflictRenames)
+		}
+		candidate = conflictName(name, n, true)
 	}
+}
 
-	var fur FileUploadResponse
-	if err := json.Unmarshal(buf.Bytes(), &fur); err != nil {
-		return nil, nil, fmt.Errorf("Error json.Unmarshal(&fur): %v. Body: %v", err, buf.String())
+type uploadPreflightRequest struct {
+	Name   string                  `json:"name"`
+	Size   int64                   `json:"size"`
+	Parent FileUploadRequestParent `json:"parent"`
+}
+
+type uploadPreflightConflict struct {
+	Conflicts struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Sha1 string `json:"sha1"`
+	} `json:"conflicts"`
+}
+
+// findIdenticalFile uses an upload preflight check to find a file named name
+// in folderID, returning it only if its size and SHA1 match. It returns nil if
+// there's no such file.
+func (c *Client) findIdenticalFile(name, folderID string, size int64, sha1Hex string) (*FileEntry, error) {
+	upc, err := c.uploadPreflight(name, folderID, size)
+	if err != nil || upc == nil {
+		// No conflict: nothing by that name yet
+		return nil, err
+	}
+	if upc.Conflicts.Type != ItemTypeFile || !strings.EqualFold(upc.Conflicts.Sha1, sha1Hex) {
+		return nil, nil
 	}
 
-	// Add status code for later inspection
-	fur.Status = resp.StatusCode
+	fe, err := c.FileGetInfo(upc.Conflicts.ID)
+	if err != nil {
+		return nil, err
+	}
+	if fe.Size != size {
+		return nil, nil
+	}
 
-	return &fur, nil, nil
+	return fe, nil
 }
 
 func (c *Client) FileUploadVersionFromPath(localFilepath, boxFileID string) (*FileUploadResponse, *FileUploadResponseError, error) {
+	return c.FileUploadVersionFromPathWithOptions(localFilepath, boxFileID, nil)
+}
+
+func (c *Client) FileUploadVersionFromPathWithOptions(localFilepath, boxFileID string, opts *UploadOptions) (*FileUploadResponse, *FileUploadResponseError, error) {
 	// Validation
 	if localFilepath == "" {
 		return nil, nil, errors.New("No localFilepath provided")
@@ -224,6 +430,9 @@ func (c *Client) FileUploadVersionFromPath(localFilepath, boxFileID string) (*Fi
 	if boxFileID == "" {
 		return nil, nil, errors.New("No boxFileID provided")
 	}
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
 
 	Url, err := url.Parse(fmt.Sprintf("%s/files/%s/content", c.UploadBaseURL, boxFileID))
 	if err != nil {
@@ -237,55 +446,132 @@ func (c *Client) FileUploadVersionFromPath(localFilepath, boxFileID string) (*Fi
 	}
 	defer file.Close()
 
-	fileContents, err := ioutil.ReadAll(file)
+	fi, err := file.Stat()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	fi, err := file.Stat()
-	if err != nil {
-		return nil, nil, err
+	// The Box file keeps its name unless a new one is asked for explicitly
+	if opts.Name != "" {
+		if err := ValidateItemName(opts.Name); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	if opts.SkipIfUnchanged {
+		digest := opts.SHA1
+		if digest == "" {
+			digest, err = ReaderSHA1(file)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		current, err := c.FileGetInfo(boxFileID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if strings.EqualFold(current.Sha1, digest) && opts.Name == "" {
+			return &FileUploadResponse{
+				Status:     http.StatusOK,
+				TotalCount: 1,
+				Entries:    []*FileEntry{current},
+			}, nil, nil
+		}
+
+		// Reuse the digest rather than hashing again
+		withDigest := *opts
+		withDigest.SHA1 = digest
+		opts = &withDigest
+	}
+
+	fureq := FileUploadRequest{
+		Name: opts.Name,
+	}
+
+	return c.fileUpload(Url.String(), &fureq, fi.Name(), file, opts)
+}
+
+// Multipart layout of uploads to the content endpoint. Box expects the
+// request body to be multipart/form-data with exactly two parts, in order:
+//
+//	Content-Disposition: form-data; name="attributes"
+//	{"name":"<file name>","parent":{"id":"<folder id>"}}
+//
+//	Content-Disposition: form-data; name="file"; filename="<file name>"
+//	Content-Type: application/octet-stream
+//	<file content>
+//
+// These only need changing for proxies or gateways that re-wrap the request
+// and expect different field names or a different file content type.
+var (
+	UploadAttributesFieldName = "attributes"
+	UploadFileFieldName       = "file"
+	UploadFileContentType     = "application/octet-stream"
+)
+
+// fileUpload sends a multipart upload request to the Box content endpoint at
+// uploadURL. fileName is the name given to the file part. Box requires the
+// "attributes" part to come before the "file" part; with the file part first,
+// an empty file part is rejected with an unhelpful error, so the order here
+// matters.
+func (c *Client) fileUpload(uploadURL string, fureq *FileUploadRequest, fileName string, r io.Reader, opts *UploadOptions) (*FileUploadResponse, *FileUploadResponseError, error) {
 	var (
 		body   = &bytes.Buffer{}
 		writer = multipart.NewWriter(body)
 	)
 
-	// write the file
-	part, err := writer.CreateFormFile("file", fi.Name())
+	// write the attributes form field first, as Box expects
+	js, err := json.Marshal(fureq)
 	if err != nil {
 		return nil, nil, err
 	}
-	part.Write(fileContents)
 
-	// write the other form fields we need
-	fureq := FileUploadRequest{
-		Name: fi.Name(),
-	}
-	js, err := json.Marshal(&fureq)
+	err = writer.WriteField(UploadAttributesFieldName, string(js))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	err = writer.WriteField("attributes", string(js))
+	// write the file (which may be zero-length), hashing it on the way
+	// unless the caller already knows the digest
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(UploadFileFieldName), quoteEscaper.Replace(fileName)))
+	partHeader.Set("Content-Type", UploadFileContentType)
+	part, err := writer.CreatePart(partHeader)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	err = writer.Close()
+	var (
+		w      io.Writer = part
+		hasher           = sha1.New()
+	)
+	if opts.SHA1 == "" {
+		w = io.MultiWriter(part, hasher)
+	}
+	size, err := io.Copy(w, r)
 	if err != nil {
 		return nil, nil, err
 	}
+	digest := opts.SHA1
+	if digest == "" {
+		digest = hex.EncodeToString(hasher.Sum(nil))
+	}
 
-	req, err := http.NewRequest("POST", Url.String(), body)
+	err = writer.Close()
 	if err != nil {
 		return nil, nil, err
 	}
-	req.Header.Add("Content-Type", writer.FormDataContentType())
+
+	req, err := http.NewRequest("POST", uploadURL, body)
 	if err != nil {
 		return nil, nil, err
 	}
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	// Box takes the content's SHA1 (not MD5) in this header
+	req.Header.Set("Content-MD5", digest)
 
 	// make request with valid access token
 	resp, err := c.HttpDo(req)
@@ -302,6 +588,9 @@ func (c *Client) FileUploadVersionFromPath(localFilepath, boxFileID string) (*Fi
 	if !(resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated) {
 		var fure FileUploadResponseError
 		if err := json.Unmarshal(buf.Bytes(), &fure); err != nil {
+			if size == 0 {
+				return nil, nil, fmt.Errorf("Box rejected upload of zero-length file %q (HTTP %v). Body: %v", fileName, resp.StatusCode, buf.String())
+			}
 			return nil, nil, fmt.Errorf("Error json.Unmarshal(&fure): %v. Body: %v", err, buf.String())
 		}
 		return nil, &fure, nil
@@ -315,9 +604,63 @@ func (c *Client) FileUploadVersionFromPath(localFilepath, boxFileID string) (*Fi
 	// Add status code for later inspection
 	fur.Status = resp.StatusCode
 
+	// The upload stands even if a hook fails, so report both
+	if err := c.uploadSucceeded(&fur, opts); err != nil {
+		return &fur, nil, err
+	}
+
 	return &fur, nil, nil
 }
 
+// uploadSucceeded runs the OnSuccess callback and OnSuccessWebhook of opts for
+// the file of a successful upload.
+func (c *Client) uploadSucceeded(fur *FileUploadResponse, opts *UploadOptions) error {
+	if opts.OnSuccess == nil && opts.OnSuccessWebhook == "" {
+		return nil
+	}
+	if len(fur.Entries) == 0 {
+		return errors.New("Box returned no file entry for the upload, not running success hooks")
+	}
+	fe := fur.Entries[0]
+
+	if opts.OnSuccess != nil {
+		if err := opts.OnSuccess(fe); err != nil {
+			return fmt.Errorf("Upload succeeded but OnSuccess failed: %w", err)
+		}
+	}
+
+	if opts.OnSuccessWebhook != "" {
+		js, err := json.Marshal(fe)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest("POST", opts.OnSuccessWebhook, bytes.NewReader(js))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		// Not HttpDo, so the Box access token isn't sent to a third party
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			return fmt.Errorf("Upload succeeded but OnSuccessWebhook failed: %w", err)
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("Upload succeeded but OnSuccessWebhook returned %v", resp.Status)
+		}
+	}
+
+	return nil
+}
+
+// quoteEscaper escapes quoted Content-Disposition parameters the same way as
+// multipart.Writer.CreateFormFile.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
 func (c *Client) FileDownload(boxFileID string) (*http.Response, error) {
 	if boxFileID == "" {
 		return nil, errors.New("No boxFileID provided")
@@ -333,9 +676,27 @@ func (c *Client) FileDownload(boxFileID string) (*http.Response, error) {
 		return nil, err
 	}
 
+	deadline := time.Now().Add(FileDownloadRetryTimeout)
+
 	resp, err := c.HttpDo(req)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	// Box answers 202 with a Retry-After header while a recently uploaded file
+	// is still being processed; wait and retry until the content is ready
+	for resp.StatusCode == http.StatusAccepted {
+		wait := retryAfter(resp, time.Second)
+		if time.Now().Add(wait).After(deadline) {
+			break
+		}
+		resp.Body.Close()
+		time.Sleep(wait)
+
+		resp, err = c.HttpDo(req)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// spew.Dump(resp.StatusCode)
@@ -349,6 +710,74 @@ func (c *Client) FileDownload(boxFileID string) (*http.Response, error) {
 	return resp, nil
 }
 
+// FileHead issues a HEAD request for a file's content, returning the
+// response without a body. Its ContentLength and ETag header describe the
+// current content, without downloading it.
+func (c *Client) FileHead(boxFileID string) (*http.Response, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/files/%s/content", c.APIBaseURL, boxFileID))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("HEAD", Url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// ParseDownloadFilename returns the original file name from a download
+// response's Content-Disposition header. RFC 5987 encoded names (the
+// filename* parameter), which Box uses for non-ASCII names, are decoded and
+// preferred over the plain filename parameter. Any directory components are
+// stripped so the result is safe to use as a local file name.
+func ParseDownloadFilename(resp *http.Response) (string, error) {
+	if resp == nil {
+		return "", errors.New("No response provided")
+	}
+
+	cd := resp.Header.Get("Content-Disposition")
+	if cd == "" {
+		return "", errors.New("Response has no Content-Disposition header")
+	}
+
+	_, params, err := mime.ParseMediaType(cd)
+	if err != nil {
+		return "", fmt.Errorf("Invalid Content-Disposition header %q: %v", cd, err)
+	}
+
+	name := params["filename"]
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("No usable filename in Content-Disposition header %q", cd)
+	}
+
+	return name, nil
+}
+
+// retryAfter returns the delay requested by a response's Retry-After header
+// (in seconds), or def if the header is missing or invalid.
+func retryAfter(resp *http.Response, def time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func (c *Client) FileDownloadGetContent(boxFileID string) (*bytes.Buffer, error) {
 	resp, err := c.FileDownload(boxFileID)
 	if err != nil {
@@ -361,8 +790,509 @@ func (c *Client) FileDownloadGetContent(boxFileID string) (*bytes.Buffer, error)
 
 	// Read the response body
 	buf := new(bytes.Buffer)
-	io.Copy(buf, resp.Body)
+	_, err = copyDownload(buf, resp)
 	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
 
 	return buf, nil
 }
+
+// FileDownloadReader returns a file's content as a stream, for callers that
+// don't need the HTTP response. Any status but 200 (including a file still
+// not ready after FileDownloadRetryTimeout) is returned as an error. Reading
+// fails with an error matching ErrIncompleteDownload if the stream ends
+// early. The caller must close the reader.
+func (c *Client) FileDownloadReader(boxFileID string) (io.ReadCloser, error) {
+	resp, err := c.FileDownload(boxFileID)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	return &downloadReader{ReadCloser: resp.Body, expected: resp.ContentLength}, nil
+}
+
+// downloadReader checks that a download body yields its full Content-Length
+// (expected, or -1 if unknown).
+type downloadReader struct {
+	io.ReadCloser
+	expected int64
+	read     int64
+}
+
+func (dr *downloadReader) Read(p []byte) (int, error) {
+	n, err := dr.ReadCloser.Read(p)
+	dr.read += int64(n)
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && dr.expected >= 0 && dr.read != dr.expected) {
+		err = fmt.Errorf("%w: got %d of %d bytes", ErrIncompleteDownload, dr.read, dr.expected)
+	}
+	return n, err
+}
+
+// copyDownload copies a download response body to dst, returning an error
+// matching ErrIncompleteDownload if fewer bytes than the response's
+// Content-Length arrive.
+func copyDownload(dst io.Writer, resp *http.Response) (int64, error) {
+	n, err := io.Copy(dst, resp.Body)
+	if err == io.ErrUnexpectedEOF || (err == nil && resp.ContentLength >= 0 && n != resp.ContentLength) {
+		return n, fmt.Errorf("%w: got %d of %d bytes", ErrIncompleteDownload, n, resp.ContentLength)
+	}
+	return n, err
+}
+
+// Size of each range requested by FileDownloadParallel
+var FileDownloadParallelPartSize int64 = 8 * 1024 * 1024
+
+// FileDownloadRange requests bytes start through end (inclusive) of a file's
+// content. A server that honors the range responds with 206 Partial Content;
+// a 200 response carries the whole file.
+func (c *Client) FileDownloadRange(boxFileID string, start, end int64) (*http.Response, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("Invalid byte range: %d-%d", start, end)
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/files/%s/content", c.APIBaseURL, boxFileID))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", Url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	return c.HttpDo(req)
+}
+
+// FileDownloadParallel downloads a file into w, fetching up to concurrency
+// byte ranges at once and writing each at its offset. If the size can't be
+// determined or ranges aren't honored, it falls back to a sequential
+// download.
+func (c *Client) FileDownloadParallel(boxFileID string, w io.WriterAt, concurrency int) error {
+	if w == nil {
+		return errors.New("No writer provided")
+	}
+
+	head, err := c.FileHead(boxFileID)
+	if err != nil {
+		return err
+	}
+	if head.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status code while checking file size: %v", head.Status)
+	}
+	size := head.ContentLength
+
+	if size <= 0 {
+		return c.fileDownloadSequential(boxFileID, w)
+	}
+
+	partSize := FileDownloadParallelPartSize
+	if partSize <= 0 {
+		partSize = size
+	}
+	parts := int((size + partSize - 1) / partSize)
+
+	// Fetch the first part on its own to find out whether ranges are honored
+	first, err := c.FileDownloadRange(boxFileID, 0, minInt64(partSize, size)-1)
+	if err != nil {
+		return err
+	}
+	switch first.StatusCode {
+	case http.StatusOK:
+		// Range ignored, the body is the whole file
+		_, err := copyDownload(&offsetWriter{w: w}, first)
+		first.Body.Close()
+		return err
+	case http.StatusPartialContent:
+		n, err := copyDownload(&offsetWriter{w: w}, first)
+		first.Body.Close()
+		if err != nil {
+			return err
+		}
+		if want := minInt64(partSize, size); n != want {
+			return fmt.Errorf("%w: got %d bytes of range 0-%d", ErrIncompleteDownload, n, want-1)
+		}
+	default:
+		first.Body.Close()
+		return fmt.Errorf("Unexpected status code while downloading range: %v", first.Status)
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	forEachConcurrent(parts-1, concurrency, func(i int) {
+		start := int64(i+1) * partSize
+		end := minInt64(start+partSize, size) - 1
+
+		err := c.fileDownloadPart(boxFileID, w, start, end)
+		if err != nil {
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			mu.Unlock()
+		}
+	})
+
+	return firstErr
+}
+
+func (c *Client) fileDownloadPart(boxFileID string, w io.WriterAt, start, end int64) error {
+	resp, err := c.FileDownloadRange(boxFileID, start, end)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("Unexpected status code while downloading range %d-%d: %v", start, end, resp.Status)
+	}
+
+	n, err := copyDownload(&offsetWriter{w: w, offset: start}, resp)
+	if err != nil {
+		return err
+	}
+	if n != end-start+1 {
+		return fmt.Errorf("%w: got %d bytes of range %d-%d", ErrIncompleteDownload, n, start, end)
+	}
+
+	return nil
+}
+
+func (c *Client) fileDownloadSequential(boxFileID string, w io.WriterAt) error {
+	resp, err := c.FileDownload(boxFileID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP non-200 status: %v (must manually handle via c.FileDownload() )", resp.StatusCode)
+	}
+
+	_, err = copyDownload(&offsetWriter{w: w}, resp)
+	return err
+}
+
+// offsetWriter adapts an io.WriterAt to an io.Writer writing sequentially
+// from offset.
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (ow *offsetWriter) Write(p []byte) (int, error) {
+	n, err := ow.w.WriteAt(p, ow.offset)
+	ow.offset += int64(n)
+	return n, err
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// FileGetInfo returns a file's information. If fields are given, only those
+// fields are requested; each must be one modeled by FileEntry.
+func (c *Client) FileGetInfo(boxFileID string, fields ...string) (*FileEntry, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+
+	var fe FileEntry
+	if err := ValidateFields(fields, &fe); err != nil {
+		return nil, err
+	}
+
+	var parameters url.Values
+	if len(fields) > 0 {
+		parameters = url.Values{}
+		parameters.Add("fields", strings.Join(fields, ","))
+	}
+
+	if err := c.DoJSON("GET", fmt.Sprintf("files/%s", boxFileID), parameters, nil, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+// FileGetInfoRaw is like FileGetInfo, but also returns the raw JSON response.
+// fields aren't checked against FileEntry, so fields it doesn't model can be
+// requested and read from the raw response.
+func (c *Client) FileGetInfoRaw(boxFileID string, fields ...string) (*FileEntry, []byte, error) {
+	if boxFileID == "" {
+		return nil, nil, errors.New("No boxFileID provided")
+	}
+
+	var parameters url.Values
+	if len(fields) > 0 {
+		parameters = url.Values{}
+		parameters.Add("fields", strings.Join(fields, ","))
+	}
+
+	var fe FileEntry
+	var raw []byte
+	if err := c.DoJSON("GET", fmt.Sprintf("files/%s", boxFileID), parameters, nil, &fe, CaptureRawResponse(&raw)); err != nil {
+		return nil, raw, err
+	}
+
+	return &fe, raw, nil
+}
+
+// FileGetRef is a minimal-fields variant of FileGetInfo.
+func (c *Client) FileGetRef(boxFileID string) (*FileRef, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+
+	parameters := url.Values{}
+	parameters.Add("fields", "type,id,name,sha1")
+
+	var fr FileRef
+	if err := c.DoJSON("GET", fmt.Sprintf("files/%s", boxFileID), parameters, nil, &fr); err != nil {
+		return nil, err
+	}
+
+	return &fr, nil
+}
+
+// FoldersListFileRefs is a minimal-fields variant of FoldersListItems that
+// returns only the files directly inside folderID. Only the FileRef fields are
+// requested and decoded, which keeps listing large folders cheap.
+func (c *Client) FoldersListFileRefs(folderID string) ([]*FileRef, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+
+	frs := []*FileRef{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.FolderItems, defaultFolderItemsPageSize, maxFolderItemsPageSize)
+
+	// Get all items, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("fields", "type,id,name,sha1")
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var page struct {
+			TotalCount int        `json:"total_count"`
+			Entries    []*FileRef `json:"entries"`
+			Limit      int        `json:"limit"`
+			Offset     int        `json:"offset"`
+		}
+		if err := c.DoJSON("GET", fmt.Sprintf("folders/%s/items", folderID), parameters, nil, &page); err != nil {
+			return frs, err
+		}
+
+		for _, fr := range page.Entries {
+			if fr.Type == ItemTypeFile {
+				frs = append(frs, fr)
+			}
+		}
+
+		// Use the values returned by the API response, not values passed in request
+		offset = page.Offset + page.Limit
+
+		if offset >= page.TotalCount || len(page.Entries) == 0 {
+			break
+		}
+	}
+
+	return frs, nil
+}
+
+type CopyOptions struct {
+	Name         string // Name for the copy; defaults to the source file's name
+	Version      string // ID of a previous file version to copy instead of the current one
+	CopyMetadata bool   // Also copy the source file's metadata instances to the copy
+}
+
+type fileCopyRequest struct {
+	Name    string                  `json:"name,omitempty"`
+	Version string                  `json:"version,omitempty"`
+	Parent  FileUploadRequestParent `json:"parent"`
+}
+
+// FileCopy copies a file into destFolderID. When opts.CopyMetadata is set and
+// some metadata could not be applied to the copy, the new file is returned
+// together with a *MetadataCopyError.
+func (c *Client) FileCopy(boxFileID, destFolderID string, opts *CopyOptions) (*FileEntry, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+	if destFolderID == "" {
+		return nil, errors.New("No destFolderID provided")
+	}
+	if opts == nil {
+		opts = &CopyOptions{}
+	}
+	if opts.Name != "" {
+		if err := ValidateItemName(opts.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	fcr := fileCopyRequest{
+		Name:    opts.Name,
+		Version: opts.Version,
+		Parent: FileUploadRequestParent{
+			ID: destFolderID,
+		},
+	}
+
+	var fe FileEntry
+	if err := c.DoJSON("POST", fmt.Sprintf("files/%s/copy", boxFileID), nil, &fcr, &fe); err != nil {
+		return nil, err
+	}
+
+	if opts.CopyMetadata {
+		if err := c.fileCopyMetadata(boxFileID, fe.ID); err != nil {
+			return &fe, err
+		}
+	}
+
+	return &fe, nil
+}
+
+type FileVersionsResponse struct {
+	TotalCount int            `json:"total_count"`
+	Entries    []*FileVersion `json:"entries"`
+	Limit      int            `json:"limit"`
+	Offset     int            `json:"offset"`
+}
+
+type FileVersion struct {
+	Type       string    `json:"type"`
+	ID         string    `json:"id"`
+	Sha1       string    `json:"sha1"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	CreatedAt  string    `json:"created_at"`
+	ModifiedAt string    `json:"modified_at"`
+	ModifiedBy *MiniUser `json:"modified_by"`
+	TrashedAt  string    `json:"trashed_at"`
+	PurgedAt   string    `json:"purged_at"`
+}
+
+// FileListVersions returns a file's previous versions. Box doesn't include
+// the current version in this list.
+func (c *Client) FileListVersions(boxFileID string) ([]*FileVersion, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+
+	fvs := []*FileVersion{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.FileVersions, defaultFileVersionsPageSize, maxFileVersionsPageSize)
+
+	// Get all versions, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var fvr FileVersionsResponse
+		if err := c.DoJSON("GET", fmt.Sprintf("files/%s/versions", boxFileID), parameters, nil, &fvr); err != nil {
+			return fvs, err
+		}
+
+		fvs = append(fvs, fvr.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = fvr.Offset + fvr.Limit
+
+		if offset >= fvr.TotalCount || len(fvr.Entries) == 0 {
+			break
+		}
+	}
+
+	return fvs, nil
+}
+
+// FileVersionsStorage returns the total size in bytes of a file's previous
+// versions, i.e. the storage used by its version history beyond the current
+// version.
+func (c *Client) FileVersionsStorage(boxFileID string) (int64, error) {
+	fvs, err := c.FileListVersions(boxFileID)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, fv := range fvs {
+		total += fv.Size
+	}
+
+	return total, nil
+}
+
+// FileDeleteVersion removes a previous version of a file. Box moves the
+// version to the trash, where it's permanently purged according to the
+// enterprise's trash retention policy, and it no longer counts against the
+// file's version history. If Box refuses because version deletion is disabled
+// (or the caller lacks permission), the returned error wraps
+// ErrVersionDeletionDisabled.
+func (c *Client) FileDeleteVersion(boxFileID, versionID string) error {
+	if boxFileID == "" {
+		return errors.New("No boxFileID provided")
+	}
+	if versionID == "" {
+		return errors.New("No versionID provided")
+	}
+
+	err := c.DoJSON("DELETE", fmt.Sprintf("files/%s/versions/%s", boxFileID, versionID), nil, nil, nil)
+	if ae, ok := err.(*APIError); ok && ae.Status == http.StatusForbidden {
+		return fmt.Errorf("%w: %v", ErrVersionDeletionDisabled, ae)
+	}
+
+	return err
+}
+
+type itemMoveRequest struct {
+	Name   string                  `json:"name,omitempty"`
+	Parent FileUploadRequestParent `json:"parent"`
+}
+
+// FileMove moves a file into destFolderID, renaming it to newName if set.
+func (c *Client) FileMove(boxFileID, destFolderID, newName string) (*FileEntry, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+	if destFolderID == "" {
+		return nil, errors.New("No destFolderID provided")
+	}
+	if newName != "" {
+		if err := ValidateItemName(newName); err != nil {
+			return nil, err
+		}
+	}
+
+	imr := itemMoveRequest{
+		Name: newName,
+		Parent: FileUploadRequestParent{
+			ID: destFolderID,
+		},
+	}
+
+	var fe FileEntry
+	if err := c.DoJSON("PUT", fmt.Sprintf("files/%s", boxFileID), nil, &imr, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
@@ -0,0 +1,294 @@
+package box
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testUploadPart is one part of a multipart upload request.
+type testUploadPart struct {
+	FormName    string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// testUploadRequest is a multipart upload request as the fake Box server
+// received it.
+type testUploadRequest struct {
+	Header http.Header
+	Parts  []testUploadPart
+}
+
+// readUploadRequest parses a multipart upload request, keeping its parts in
+// the order they were sent.
+func readUploadRequest(r *http.Request) (*testUploadRequest, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "multipart/form-data" {
+		return nil, fmt.Errorf("Content-Type is %q, want multipart/form-data", mediaType)
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	ur := &testUploadRequest{Header: r.Header.Clone()}
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		ur.Parts = append(ur.Parts, testUploadPart{
+			FormName:    p.FormName(),
+			FileName:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Content:     content,
+		})
+	}
+
+	return ur, nil
+}
+
+// uploadRecorder is a fake Box upload endpoint that records every upload
+// and answers with the uploaded file, owned by the As-User user if one was
+// given.
+type uploadRecorder struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	uploads []*testUploadRequest
+}
+
+func (ur *uploadRecorder) handle(w http.ResponseWriter, r *http.Request) {
+	upload, err := readUploadRequest(r)
+	if err != nil {
+		ur.t.Errorf("reading upload request: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ur.mu.Lock()
+	ur.uploads = append(ur.uploads, upload)
+	ur.mu.Unlock()
+
+	var fureq FileUploadRequest
+	if len(upload.Parts) > 0 {
+		json.Unmarshal(upload.Parts[0].Content, &fureq)
+	}
+	ownerID := r.Header.Get("As-User")
+	if ownerID == "" {
+		ownerID = "service-account"
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"total_count":1,"entries":[{"type":"file","id":"99","name":%q,"size":%d,"owned_by":{"type":"user","id":%q}}]}`,
+		fureq.Name, len(upload.Parts[len(upload.Parts)-1].Content), ownerID)
+}
+
+func (ur *uploadRecorder) last() *testUploadRequest {
+	ur.mu.Lock()
+	defer ur.mu.Unlock()
+	if len(ur.uploads) == 0 {
+		ur.t.Fatal("no upload received")
+	}
+	return ur.uploads[len(ur.uploads)-1]
+}
+
+func TestFileUploadMultipartStructure(t *testing.T) {
+	rec := &uploadRecorder{t: t}
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/files/content" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rec.handle(w, r)
+	})
+	defer done()
+
+	for _, content := range []string{"hello, box", ""} {
+		fur, fure, err := c.FileUploadFromReader(strings.NewReader(content), `report "final".txt`, "12345")
+		if err != nil || fure != nil {
+			t.Fatalf("upload of %d bytes: err = %v, fure = %v", len(content), err, fure)
+		}
+		if fur.Status != http.StatusCreated || len(fur.Entries) != 1 {
+			t.Fatalf("upload of %d bytes: unexpected response %+v", len(content), fur)
+		}
+
+		upload := rec.last()
+		if len(upload.Parts) != 2 {
+			t.Fatalf("upload of %d bytes: got %d parts, want 2", len(content), len(upload.Parts))
+		}
+
+		// Box needs the attributes before the file
+		attrs := upload.Parts[0]
+		if attrs.FormName != UploadAttributesFieldName {
+			t.Errorf("first part is %q, want %q", attrs.FormName, UploadAttributesFieldName)
+		}
+		var fureq FileUploadRequest
+		if err := json.Unmarshal(attrs.Content, &fureq); err != nil {
+			t.Fatalf("attributes aren't JSON: %v: %s", err, attrs.Content)
+		}
+		if fureq.Name != `report "final".txt` || fureq.Parent.ID != "12345" {
+			t.Errorf("attributes = %s, want name and parent.id of the upload", attrs.Content)
+		}
+
+		file := upload.Parts[1]
+		if file.FormName != UploadFileFieldName {
+			t.Errorf("second part is %q, want %q", file.FormName, UploadFileFieldName)
+		}
+		if file.FileName != `report "final".txt` {
+			t.Errorf("file part filename = %q, want the upload name", file.FileName)
+		}
+		if file.ContentType != UploadFileContentType {
+			t.Errorf("file part Content-Type = %q, want %q", file.ContentType, UploadFileContentType)
+		}
+		if !bytes.Equal(file.Content, []byte(content)) {
+			t.Errorf("file part content = %q, want %q", file.Content, content)
+		}
+	}
+}
+
+func TestFileUploadZeroLength(t *testing.T) {
+	// SHA1 of no bytes at all
+	const emptySHA1 = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+
+	rec := &uploadRecorder{t: t}
+	c, _, done := newTestClient(t, rec.handle)
+	defer done()
+
+	emptyFile, err := ioutil.TempFile("", "box-test-empty-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	emptyFile.Close()
+	defer os.Remove(emptyFile.Name())
+
+	uploads := map[string]func() (*FileUploadResponse, *FileUploadResponseError, error){
+		"reader": func() (*FileUploadResponse, *FileUploadResponseError, error) {
+			return c.FileUploadFromReader(bytes.NewReader(nil), "empty.txt", "0")
+		},
+		"file": func() (*FileUploadResponse, *FileUploadResponseError, error) {
+			return c.FileUploadFromPath(emptyFile.Name(), "0")
+		},
+	}
+	for name, upload := range uploads {
+		fur, fure, err := upload()
+		if err != nil || fure != nil {
+			t.Fatalf("empty %s upload: err = %v, fure = %v", name, err, fure)
+		}
+		if len(fur.Entries) != 1 || fur.Entries[0].Size != 0 {
+			t.Errorf("empty %s upload: unexpected response %+v", name, fur)
+		}
+
+		upload := rec.last()
+		if got := upload.Header.Get("Content-MD5"); got != emptySHA1 {
+			t.Errorf("empty %s upload: SHA1 header = %q, want %q", name, got, emptySHA1)
+		}
+		if len(upload.Parts) != 2 || upload.Parts[1].FormName != UploadFileFieldName {
+			t.Fatalf("empty %s upload: file part missing from %+v", name, upload.Parts)
+		}
+		if len(upload.Parts[1].Content) != 0 {
+			t.Errorf("empty %s upload: file part has %d bytes, want 0", name, len(upload.Parts[1].Content))
+		}
+	}
+}
+
+func TestUploadAsUser(t *testing.T) {
+	const asUser = "12345"
+
+	var (
+		mu      sync.Mutex
+		seen    = map[string]string{} // "METHOD path" -> As-User header
+		uploads = &uploadRecorder{t: t}
+	)
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.Method+" "+r.URL.Path] = r.Header.Get("As-User")
+		mu.Unlock()
+
+		owner := fmt.Sprintf(`{"type":"user","id":%q}`, r.Header.Get("As-User"))
+		switch r.Method + " " + r.URL.Path {
+		case "OPTIONS /files/content":
+			// Preflight: the name is free
+			w.Write([]byte(`{}`))
+		case "POST /files/content":
+			uploads.handle(w, r)
+		case "POST /files/upload_sessions":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"type":"upload_session","id":"session-1","part_size":4,"total_parts":3}`))
+		case "PUT /files/upload_sessions/session-1":
+			var start, end, total int
+			fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+			fmt.Fprintf(w, `{"part":{"part_id":"p%d","offset":%d,"size":%d}}`, start, start, end-start+1)
+		case "POST /files/upload_sessions/session-1/commit":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"total_count":1,"entries":[{"type":"file","id":"100","name":"big.bin","owned_by":%s}]}`, owner)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+	c.AsUserID = asUser
+
+	// Multipart upload, with a preflight check to settle name conflicts
+	fur, fure, err := c.FileUploadFromReaderWithOptions(strings.NewReader("content"), "small.txt", "0", &UploadOptions{OnNameConflict: UploadConflictRename})
+	if err != nil || fure != nil {
+		t.Fatalf("multipart upload: err = %v, fure = %v", err, fure)
+	}
+	if got := fur.Entries[0].OwnedBy.ID; got != asUser {
+		t.Errorf("multipart upload owned by %q, want %q", got, asUser)
+	}
+
+	// Upload session
+	bigFile, err := ioutil.TempFile("", "box-test-big-*.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bigFile.WriteString("0123456789")
+	bigFile.Close()
+	defer os.Remove(bigFile.Name())
+
+	fur, _, err = c.FileUploadChunkedFromPath(bigFile.Name(), "0", "")
+	if err != nil {
+		t.Fatalf("chunked upload: %v", err)
+	}
+	if got := fur.Entries[0].OwnedBy.ID; got != asUser {
+		t.Errorf("chunked upload owned by %q, want %q", got, asUser)
+	}
+
+	for _, req := range []string{
+		"OPTIONS /files/content",
+		"POST /files/content",
+		"POST /files/upload_sessions",
+		"PUT /files/upload_sessions/session-1",
+		"POST /files/upload_sessions/session-1/commit",
+	} {
+		got, ok := seen[req]
+		if !ok {
+			t.Errorf("%s: request not made", req)
+		} else if got != asUser {
+			t.Errorf("%s: As-User = %q, want %q", req, got, asUser)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package box
+
+import (
+	"path"
+	"strings"
+)
+
+// Extension categories used by the FileEntry type helpers; extensions are
+// lowercase and without the leading dot.
+var (
+	ImageExtensions     = []string{"bmp", "gif", "heic", "jpeg", "jpg", "png", "svg", "tif", "tiff", "webp"}
+	OfficeDocExtensions = []string{"doc", "docm", "docx", "dot", "dotx", "odp", "ods", "odt", "pot", "potx", "pps", "ppsx", "ppt", "pptm", "pptx", "rtf", "xls", "xlsb", "xlsm", "xlsx", "xlt", "xltx"}
+)
+
+// mimeTypes maps extensions to MIME types. It is kept in the package, rather
+// than using mime.TypeByExtension, so results don't depend on the host's MIME
+// database.
+var mimeTypes = map[string]string{
+	"bmp":     "image/bmp",
+	"csv":     "text/csv",
+	"doc":     "application/msword",
+	"docx":    "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"gif":     "image/gif",
+	"heic":    "image/heic",
+	"htm":     "text/html",
+	"html":    "text/html",
+	"jpeg":    "image/jpeg",
+	"jpg":     "image/jpeg",
+	"json":    "application/json",
+	"key":     "application/vnd.apple.keynote",
+	"md":      "text/markdown",
+	"mov":     "video/quicktime",
+	"mp3":     "audio/mpeg",
+	"mp4":     "video/mp4",
+	"numbers": "application/vnd.apple.numbers",
+	"odp":     "application/vnd.oasis.opendocument.presentation",
+	"ods":     "application/vnd.oasis.opendocument.spreadsheet",
+	"odt":     "application/vnd.oasis.opendocument.text",
+	"pages":   "application/vnd.apple.pages",
+	"pdf":     "application/pdf",
+	"png":     "image/png",
+	"ppt":     "application/vnd.ms-powerpoint",
+	"pptx":    "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"rtf":     "application/rtf",
+	"svg":     "image/svg+xml",
+	"tif":     "image/tiff",
+	"tiff":    "image/tiff",
+	"txt":     "text/plain",
+	"wav":     "audio/wav",
+	"webp":    "image/webp",
+	"xls":     "application/vnd.ms-excel",
+	"xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"xml":     "application/xml",
+	"zip":     "application/zip",
+}
+
+// Ext returns the file's lowercase extension without the leading dot. It uses
+// the Extension field when Box returned it, and the file name otherwise.
+func (fe *FileEntry) Ext() string {
+	ext := fe.Extension
+	if ext == "" {
+		ext = strings.TrimPrefix(path.Ext(fe.Name), ".")
+	}
+	return strings.ToLower(ext)
+}
+
+// IsImage reports whether the file is an image, judged by its extension.
+func (fe *FileEntry) IsImage() bool {
+	return stringInSlice(fe.Ext(), ImageExtensions)
+}
+
+// IsOfficeDoc reports whether the file is a word processing, spreadsheet or
+// presentation document in a Microsoft Office or OpenDocument format.
+func (fe *FileEntry) IsOfficeDoc() bool {
+	return stringInSlice(fe.Ext(), OfficeDocExtensions)
+}
+
+// MimeType returns the file's MIME type, judged by its extension, or
+// "application/octet-stream" if the extension is unknown.
+func (fe *FileEntry) MimeType() string {
+	if mt, ok := mimeTypes[fe.Ext()]; ok {
+		return mt
+	}
+	return "application/octet-stream"
+}
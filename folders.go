@@ -0,0 +1,587 @@
+package box
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	ItemTypeFile    = "file"
+	ItemTypeFolder  = "folder"
+	ItemTypeWebLink = "web_link"
+)
+
+var (
+	FolderSyncStateSynced          = "synced"
+	FolderSyncStateNotSynced       = "not_synced"
+	FolderSyncStatePartiallySynced = "partially_synced"
+)
+
+// How long FolderCopy waits for Box to finish copying a large folder in the
+// background
+var FolderCopyTimeout = 10 * time.Minute
+
+type FolderItemsResponse struct {
+	TotalCount int           `json:"total_count"`
+	Entries    []*FolderItem `json:"entries"`
+	Limit      int           `json:"limit"`
+	Offset     int           `json:"offset"`
+}
+
+type FolderItem struct {
+	Type       string `json:"type,omitempty"`
+	ID         string `json:"id,omitempty"`
+	SequenceID string `json:"sequence_id,omitempty"`
+	Etag       string `json:"etag,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Size       int64  `json:"size,omitempty"`
+	Sha1       string `json:"sha1,omitempty"`
+	ParentID   string `json:"-"` // Set by FoldersWalk; not returned by Box
+}
+
+type FolderEntry struct {
+	Type              string      `json:"type,omitempty"`
+	ID                string      `json:"id,omitempty"`
+	SequenceID        string      `json:"sequence_id,omitempty"`
+	Etag              string      `json:"etag,omitempty"`
+	Name              string      `json:"name,omitempty"`
+	Description       string      `json:"description,omitempty"`
+	Size              int64       `json:"size,omitempty"`
+	CreatedAt         string      `json:"created_at,omitempty"`
+	ModifiedAt        string      `json:"modified_at,omitempty"`
+	TrashedAt         string      `json:"trashed_at,omitempty"`
+	PurgedAt          string      `json:"purged_at,omitempty"`
+	CreatedBy         *MiniUser   `json:"created_by,omitempty"`
+	ModifiedBy        *MiniUser   `json:"modified_by,omitempty"`
+	OwnedBy           *MiniUser   `json:"owned_by,omitempty"`
+	Parent            *FolderItem `json:"parent,omitempty"`
+	ItemStatus        string      `json:"item_status,omitempty"`
+	SharedLink        *SharedLink `json:"shared_link,omitempty"`
+	SyncState         string      `json:"sync_state,omitempty"`
+	HasCollaborations bool        `json:"has_collaborations,omitempty"`
+	IsExternallyOwned bool        `json:"is_externally_owned,omitempty"`
+
+	CanNonOwnersInvite                    bool `json:"can_non_owners_invite,omitempty"`
+	CanNonOwnersViewCollaborators         bool `json:"can_non_owners_view_collaborators,omitempty"`
+	IsCollaborationRestrictedToEnterprise bool `json:"is_collaboration_restricted_to_enterprise,omitempty"`
+
+	WatermarkInfo *WatermarkInfo `json:"watermark_info,omitempty"` // Only returned when requested in fields
+}
+
+// FolderUpdate holds the folder attributes to change with FoldersUpdate. Nil
+// fields are left unchanged; pointers let false and "" be set explicitly.
+type FolderUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+
+	// Collaboration governance: set CanNonOwnersInvite to false so only owners
+	// (and co-owners) can invite collaborators
+	CanNonOwnersInvite                    *bool `json:"can_non_owners_invite,omitempty"`
+	CanNonOwnersViewCollaborators         *bool `json:"can_non_owners_view_collaborators,omitempty"`
+	IsCollaborationRestrictedToEnterprise *bool `json:"is_collaboration_restricted_to_enterprise,omitempty"`
+}
+
+type MiniUser struct {
+	Type  string `json:"type,omitempty"`
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Login string `json:"login,omitempty"`
+}
+
+func (c *Client) FoldersListItems(folderID string) ([]*FolderItem, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+
+	fis := []*FolderItem{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.FolderItems, defaultFolderItemsPageSize, maxFolderItemsPageSize)
+
+	// Get all items, looping through API pages
+	for true {
+		fir, err := c.FoldersListItemsPage(folderID, offset, limit)
+		if err != nil {
+			return fis, err
+		}
+
+		fis = append(fis, fir.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = fir.Offset + fir.Limit
+
+		if offset >= fir.TotalCount || len(fir.Entries) == 0 {
+			break
+		}
+	}
+
+	return fis, nil
+}
+
+// FoldersListItemsPage returns a single page of a folder's items, for callers
+// that drive pagination themselves. The next page starts at the returned
+// Offset + Limit, and there are no more once that reaches TotalCount. A limit
+// of 0 uses Box's default page size.
+func (c *Client) FoldersListItemsPage(folderID string, offset, limit int) (*FolderItemsResponse, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("Invalid offset or limit: %d, %d", offset, limit)
+	}
+
+	parameters := url.Values{}
+	parameters.Add("fields", "type,id,sequence_id,etag,name,size,sha1")
+	parameters.Add("offset", fmt.Sprintf("%d", offset))
+	if limit > 0 {
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+	}
+
+	var fir FolderItemsResponse
+	if err := c.DoJSON("GET", fmt.Sprintf("folders/%s/items", folderID), parameters, nil, &fir); err != nil {
+		return nil, err
+	}
+
+	for _, fi := range fir.Entries {
+		fi.ParentID = folderID
+	}
+
+	return &fir, nil
+}
+
+// FoldersWalk calls fn for every item beneath folderID, depth first, descending
+// into each subfolder after fn has been called for it. If fn returns an error
+// the walk stops and that error is returned.
+func (c *Client) FoldersWalk(folderID string, fn func(item *FolderItem) error) error {
+	return c.FoldersWalkContext(context.Background(), folderID, fn, nil)
+}
+
+// WalkProgress counts the items a folder walk has visited so far.
+type WalkProgress struct {
+	FoldersVisited int
+	FilesVisited   int
+}
+
+// FoldersWalkContext is FoldersWalk for long walks: it stops with ctx's error
+// once ctx is canceled, checked before each page of items is requested and
+// before each item is visited. If progress isn't nil, it's called after every
+// visited item with the running totals. Page requests are retried while Box
+// rate limits them or is unavailable.
+func (c *Client) FoldersWalkContext(ctx context.Context, folderID string, fn func(item *FolderItem) error, progress func(WalkProgress)) error {
+	if fn == nil {
+		return errors.New("No walk function provided")
+	}
+
+	var wp WalkProgress
+	return c.foldersWalk(ctx, folderID, fn, progress, &wp)
+}
+
+func (c *Client) foldersWalk(ctx context.Context, folderID string, fn func(item *FolderItem) error, progress func(WalkProgress), wp *WalkProgress) error {
+	offset := 0
+	limit := pageSize(c.PageSizes.FolderItems, defaultFolderItemsPageSize, maxFolderItemsPageSize)
+
+	// Visit items page by page, so cancellation isn't held up listing a huge folder
+	for true {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var fir *FolderItemsResponse
+		err := withRateLimitRetry(func() error {
+			var err error
+			fir, err = c.FoldersListItemsPage(folderID, offset, limit)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, fi := range fir.Entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(fi); err != nil {
+				return err
+			}
+
+			if fi.Type == ItemTypeFolder {
+				wp.FoldersVisited++
+			} else {
+				wp.FilesVisited++
+			}
+			if progress != nil {
+				progress(*wp)
+			}
+
+			if fi.Type == ItemTypeFolder {
+				if err := c.foldersWalk(ctx, fi.ID, fn, progress, wp); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Use the values returned by the API response, not values passed in request
+		offset = fir.Offset + fir.Limit
+
+		if offset >= fir.TotalCount || len(fir.Entries) == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// FolderSize returns the total size in bytes of all files beneath folderID,
+// along with the number of files and subfolders found. Large trees take many
+// requests, so it stops with ctx's error once ctx is canceled.
+func (c *Client) FolderSize(ctx context.Context, folderID string) (totalBytes int64, fileCount, folderCount int, err error) {
+	err = c.FoldersWalkContext(ctx, folderID, func(fi *FolderItem) error {
+		switch fi.Type {
+		case ItemTypeFile:
+			totalBytes += fi.Size
+			fileCount++
+		case ItemTypeFolder:
+			folderCount++
+		}
+		return nil
+	}, nil)
+	return totalBytes, fileCount, folderCount, err
+}
+
+func (c *Client) FoldersGetFolder(folderID string) (*FolderEntry, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/folders/%s", c.APIBaseURL, folderID))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", Url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// make request with valid access token
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code while executing API request: %v", resp.Status)
+	}
+
+	var fe FolderEntry
+	if err := json.Unmarshal(buf.Bytes(), &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+// FolderSetSyncState controls whether Box Drive syncs the folder. state must
+// be one of the FolderSyncState* values.
+func (c *Client) FolderSetSyncState(folderID, state string) (*FolderEntry, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+	if !stringInSlice(state, []string{FolderSyncStateSynced, FolderSyncStateNotSynced, FolderSyncStatePartiallySynced}) {
+		return nil, fmt.Errorf("Invalid sync state: %q", state)
+	}
+
+	js, err := json.Marshal(&FolderEntry{SyncState: state})
+	if err != nil {
+		return nil, err
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/folders/%s", c.APIBaseURL, folderID))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", Url.String(), bytes.NewReader(js))
+	if err != nil {
+		return nil, err
+	}
+
+	// make request with valid access token
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code while executing API request: %v", resp.Status)
+	}
+
+	var fe FolderEntry
+	if err := json.Unmarshal(buf.Bytes(), &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+type folderCreateRequest struct {
+	Name   string                  `json:"name"`
+	Parent FileUploadRequestParent `json:"parent"`
+}
+
+func (c *Client) FoldersCreate(name, parentFolderID string) (*FolderEntry, error) {
+	if err := ValidateItemName(name); err != nil {
+		return nil, err
+	}
+	if parentFolderID == "" {
+		return nil, errors.New("No parentFolderID provided")
+	}
+
+	fcr := folderCreateRequest{
+		Name: name,
+		Parent: FileUploadRequestParent{
+			ID: parentFolderID,
+		},
+	}
+
+	var fe FolderEntry
+	if err := c.DoJSON("POST", "folders", nil, &fcr, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+// FoldersUpdate changes the attributes of a folder set in upd.
+func (c *Client) FoldersUpdate(folderID string, upd *FolderUpdate) (*FolderEntry, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+	if upd == nil {
+		return nil, errors.New("No folder update provided")
+	}
+	if upd.Name != nil {
+		if err := ValidateItemName(*upd.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	parameters := url.Values{}
+	parameters.Add("fields", "type,id,name,description,can_non_owners_invite,can_non_owners_view_collaborators,is_collaboration_restricted_to_enterprise")
+
+	var fe FolderEntry
+	if err := c.DoJSON("PUT", fmt.Sprintf("folders/%s", folderID), parameters, upd, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+// FoldersCreateWithSharedLink creates a folder and gives it a shared link.
+// Box can't do both in one request, so if creating the link fails the new,
+// still empty folder is deleted again rather than left without its link.
+func (c *Client) FoldersCreateWithSharedLink(name, parentFolderID string, opts *SharedLinkOptions) (*FolderEntry, error) {
+	fe, err := c.FoldersCreate(name, parentFolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	sl, err := c.FolderCreateSharedLink(fe.ID, opts)
+	if err != nil {
+		if c.FoldersDelete(fe.ID, false) == nil {
+			c.FoldersPermanentDelete(fe.ID)
+		}
+		return nil, err
+	}
+	fe.SharedLink = sl
+
+	return fe, nil
+}
+
+// FoldersDelete moves a folder to the trash, where it can be restored until it
+// is purged. Unless recursive is set, only an empty folder is deleted; for a
+// non-empty one the error matches ErrFolderNotEmpty.
+func (c *Client) FoldersDelete(folderID string, recursive bool) error {
+	if folderID == "" {
+		return errors.New("No folderID provided")
+	}
+
+	parameters := url.Values{}
+	parameters.Add("recursive", fmt.Sprintf("%t", recursive))
+
+	return c.DoJSON("DELETE", fmt.Sprintf("folders/%s", folderID), parameters, nil, nil)
+}
+
+// FoldersPermanentDelete removes a folder that is already in the trash, and
+// everything in it, for good. This can't be undone.
+func (c *Client) FoldersPermanentDelete(folderID string) error {
+	return c.trashPermanentDelete(ItemTypeFolder, folderID)
+}
+
+// FolderCopy copies a folder and everything in it into destFolderID, naming
+// the copy newName if set, and returns the new folder. Box may copy large
+// folders in the background, answering 202 with a status URL to poll; that
+// is followed until the copy is done or FolderCopyTimeout elapses.
+func (c *Client) FolderCopy(folderID, destFolderID, newName string) (*FolderEntry, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+	if destFolderID == "" {
+		return nil, errors.New("No destFolderID provided")
+	}
+	if newName != "" {
+		if err := ValidateItemName(newName); err != nil {
+			return nil, err
+		}
+	}
+
+	imr := itemMoveRequest{
+		Name: newName,
+		Parent: FileUploadRequestParent{
+			ID: destFolderID,
+		},
+	}
+
+	resp, err := c.Do("POST", fmt.Sprintf("folders/%s/copy", folderID), nil, &imr)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(FolderCopyTimeout)
+
+	// Poll the status URL while the copy is still running
+	for resp.StatusCode == http.StatusAccepted {
+		statusURL := resp.Header.Get("Location")
+		wait := retryAfter(resp, 5*time.Second)
+		resp.Body.Close()
+		if statusURL == "" {
+			return nil, errors.New("Box is copying the folder in the background but gave no status URL")
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("Timed out after %v waiting for folder %s to be copied", FolderCopyTimeout, folderID)
+		}
+		time.Sleep(wait)
+
+		resp, err = c.Do("GET", statusURL, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	var fe FolderEntry
+	if err := json.Unmarshal(buf.Bytes(), &fe); err != nil {
+		return nil, fmt.Errorf("Error json.Unmarshal(&fe): %v. Body: %v", err, buf.String())
+	}
+
+	return &fe, nil
+}
+
+// FolderMove moves a folder into destFolderID, renaming it to newName if set.
+func (c *Client) FolderMove(folderID, destFolderID, newName string) (*FolderEntry, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+	if destFolderID == "" {
+		return nil, errors.New("No destFolderID provided")
+	}
+	if newName != "" {
+		if err := ValidateItemName(newName); err != nil {
+			return nil, err
+		}
+	}
+
+	imr := itemMoveRequest{
+		Name: newName,
+		Parent: FileUploadRequestParent{
+			ID: destFolderID,
+		},
+	}
+
+	var fe FolderEntry
+	if err := c.DoJSON("PUT", fmt.Sprintf("folders/%s", folderID), nil, &imr, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+// CopyFolderStructure recreates the folder tree rooted at srcFolderID inside
+// destParentID, without copying any file content. If placeholderFiles is set,
+// an empty file is created in place of each source file. It returns the new
+// top-level folder.
+func (c *Client) CopyFolderStructure(srcFolderID, destParentID string, placeholderFiles bool) (*FolderEntry, error) {
+	src, err := c.FoldersGetFolder(srcFolderID)
+	if err != nil {
+		return nil, err
+	}
+	if destParentID == "" {
+		return nil, errors.New("No destParentID provided")
+	}
+
+	root, err := c.FoldersCreate(src.Name, destParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map source folder IDs to their copies. Copies are also remembered so
+	// they aren't walked into when the destination is inside the source.
+	copies := map[string]string{srcFolderID: root.ID}
+	created := map[string]bool{root.ID: true}
+
+	err = c.FoldersWalk(srcFolderID, func(fi *FolderItem) error {
+		if created[fi.ID] || created[fi.ParentID] {
+			return nil
+		}
+		destID, ok := copies[fi.ParentID]
+		if !ok {
+			// Parent was skipped
+			return nil
+		}
+
+		switch fi.Type {
+		case ItemTypeFolder:
+			fe, err := c.FoldersCreate(fi.Name, destID)
+			if err != nil {
+				return err
+			}
+			copies[fi.ID] = fe.ID
+			created[fe.ID] = true
+		case ItemTypeFile:
+			if !placeholderFiles {
+				return nil
+			}
+			_, fure, err := c.FileUploadFromReader(bytes.NewReader(nil), fi.Name, destID)
+			if err != nil {
+				return err
+			}
+			if fure != nil {
+				return fmt.Errorf("Error creating placeholder for %q: %s", fi.Name, fure.Message)
+			}
+		}
+		return nil
+	})
+
+	return root, err
+}
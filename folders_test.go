@@ -0,0 +1,59 @@
+package box
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFolderSize(t *testing.T) {
+	origRateLimit := DefaultRateLimitRetryAfter
+	DefaultRateLimitRetryAfter = time.Millisecond
+	defer func() { DefaultRateLimitRetryAfter = origRateLimit }()
+
+	var (
+		mu    sync.Mutex
+		calls = map[string]int{}
+	)
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/folders/1/items":
+			io.WriteString(w, `{"total_count":2,"offset":0,"limit":1000,"entries":[{"type":"file","id":"10","size":10},{"type":"folder","id":"2"}]}`)
+		case "/folders/2/items":
+			if n == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			io.WriteString(w, `{"total_count":1,"offset":0,"limit":1000,"entries":[{"type":"file","id":"20","size":5}]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+
+	totalBytes, fileCount, folderCount, err := c.FolderSize(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totalBytes != 15 || fileCount != 2 || folderCount != 1 {
+		t.Errorf("got %d bytes in %d files and %d folders, want 15 bytes in 2 files and 1 folder", totalBytes, fileCount, folderCount)
+	}
+	if calls["/folders/2/items"] != 2 {
+		t.Errorf("rate limited folder listed %d times, want 2", calls["/folders/2/items"])
+	}
+
+	// A canceled walk stops before requesting anything
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, _, err := c.FolderSize(ctx, "1"); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
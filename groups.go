@@ -0,0 +1,134 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+type GroupsResponse struct {
+	TotalCount int           `json:"total_count"`
+	Entries    []*GroupEntry `json:"entries"`
+	Limit      int           `json:"limit"`
+	Offset     int           `json:"offset"`
+}
+
+type GroupEntry struct {
+	Type                   string `json:"type,omitempty"`
+	ID                     string `json:"id,omitempty"`
+	Name                   string `json:"name,omitempty"`
+	GroupType              string `json:"group_type,omitempty"`
+	Description            string `json:"description,omitempty"`
+	Provenance             string `json:"provenance,omitempty"`
+	ExternalSyncIdentifier string `json:"external_sync_identifier,omitempty"`
+	InvitabilityLevel      string `json:"invitability_level,omitempty"`
+	MemberViewabilityLevel string `json:"member_viewability_level,omitempty"`
+	CreatedAt              string `json:"created_at,omitempty"`
+	ModifiedAt             string `json:"modified_at,omitempty"`
+}
+
+type groupMembershipsResponse struct {
+	TotalCount int `json:"total_count"`
+}
+
+// GroupsListForEnterprise returns one page of the enterprise's groups visible
+// to the authenticated user. Box allows a limit of up to 1000; larger limits
+// are clamped to that, and a limit of 0 uses the configured PageSizes.Groups.
+func (c *Client) GroupsListForEnterprise(limit, offset int) (*GroupsResponse, error) {
+	if limit == 0 {
+		limit = c.PageSizes.Groups
+	}
+	limit = pageSize(limit, defaultGroupsPageSize, maxGroupsPageSize)
+
+	parameters := url.Values{}
+	parameters.Add("fields", "type,id,name,group_type,description,provenance,external_sync_identifier,invitability_level,member_viewability_level,created_at,modified_at")
+	parameters.Add("offset", fmt.Sprintf("%d", offset))
+	parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+	var gr GroupsResponse
+	if err := c.DoJSON("GET", "groups", parameters, nil, &gr); err != nil {
+		return nil, err
+	}
+
+	return &gr, nil
+}
+
+func (c *Client) GroupsGetAll() ([]*GroupEntry, error) {
+	ges := []*GroupEntry{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.Groups, defaultGroupsPageSize, maxGroupsPageSize)
+
+	// Get all groups, looping through API pages
+	for true {
+		gr, err := c.GroupsListForEnterprise(limit, offset)
+		if err != nil {
+			return ges, err
+		}
+
+		ges = append(ges, gr.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = gr.Offset + gr.Limit
+
+		if offset >= gr.TotalCount || len(gr.Entries) == 0 {
+			break
+		}
+	}
+
+	return ges, nil
+}
+
+// GroupsGetMemberCount returns the number of members in a group. Box doesn't
+// include this on the group itself, so it costs one request per group.
+func (c *Client) GroupsGetMemberCount(groupID string) (int, error) {
+	if groupID == "" {
+		return 0, errors.New("No groupID provided")
+	}
+
+	parameters := url.Values{}
+	parameters.Add("limit", "1")
+
+	var gmr groupMembershipsResponse
+	if err := c.DoJSON("GET", fmt.Sprintf("groups/%s/memberships", groupID), parameters, nil, &gmr); err != nil {
+		return 0, err
+	}
+
+	return gmr.TotalCount, nil
+}
+
+// GroupsListCollaborations returns the collaborations granted to a group,
+// i.e. the items the group has access to.
+func (c *Client) GroupsListCollaborations(groupID string) ([]*Collaboration, error) {
+	if groupID == "" {
+		return nil, errors.New("No groupID provided")
+	}
+
+	cs := []*Collaboration{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.GroupCollaborations, defaultGroupCollaborationsPageSize, maxGroupCollaborationsPageSize)
+
+	// Get all collaborations, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var cr CollaborationsResponse
+		if err := c.DoJSON("GET", fmt.Sprintf("groups/%s/collaborations", groupID), parameters, nil, &cr); err != nil {
+			return cs, err
+		}
+
+		cs = append(cs, cr.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = cr.Offset + cr.Limit
+
+		if offset >= cr.TotalCount || len(cr.Entries) == 0 {
+			break
+		}
+	}
+
+	return cs, nil
+}
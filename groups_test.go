@@ -0,0 +1,37 @@
+package box
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGroupsListForEnterpriseLimit(t *testing.T) {
+	var gotLimit string
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotLimit = r.URL.Query().Get("limit")
+		io.WriteString(w, `{"total_count":0,"entries":[]}`)
+	})
+	defer done()
+
+	tests := []struct {
+		limit     int
+		pageSize  int
+		wantLimit string
+	}{
+		{0, 0, "1000"},
+		{0, 50, "50"},
+		{20, 50, "20"},
+		{5000, 0, "1000"},
+		{-1, 0, "1000"},
+	}
+	for _, tt := range tests {
+		c.PageSizes.Groups = tt.pageSize
+		if _, err := c.GroupsListForEnterprise(tt.limit, 0); err != nil {
+			t.Fatal(err)
+		}
+		if gotLimit != tt.wantLimit {
+			t.Errorf("limit %d with page size %d: requested limit %s, want %s", tt.limit, tt.pageSize, gotLimit, tt.wantLimit)
+		}
+	}
+}
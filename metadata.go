@@ -0,0 +1,360 @@
+package box
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The Box Shield classification metadata template, and the field in it
+// holding the classification name
+var (
+	ClassificationTemplateKey = "securityClassification-6VMVochwUWo"
+	ClassificationField       = "Box__Security__Classification__Key"
+)
+
+// MetadataInstance is a metadata template instance applied to an item. Box
+// mixes its own "$"-prefixed keys ($template, $scope, $parent, ...) with the
+// template's field values in the same object.
+type MetadataInstance map[string]interface{}
+
+func (mi MetadataInstance) Scope() string {
+	s, _ := mi["$scope"].(string)
+	return s
+}
+
+func (mi MetadataInstance) TemplateKey() string {
+	s, _ := mi["$template"].(string)
+	return s
+}
+
+// Values returns the instance's field values, without Box's "$" keys.
+func (mi MetadataInstance) Values() map[string]interface{} {
+	vs := map[string]interface{}{}
+	for k, v := range mi {
+		if strings.HasPrefix(k, "$") {
+			continue
+		}
+		vs[k] = v
+	}
+	return vs
+}
+
+type MetadataInstancesResponse struct {
+	Entries []MetadataInstance `json:"entries"`
+	Limit   int                `json:"limit"`
+}
+
+func (c *Client) FileListMetadata(fileID string) ([]MetadataInstance, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+
+	var mir MetadataInstancesResponse
+	if err := c.DoJSON("GET", fmt.Sprintf("files/%s/metadata", fileID), nil, nil, &mir); err != nil {
+		return nil, err
+	}
+
+	return mir.Entries, nil
+}
+
+func (c *Client) FileGetMetadata(fileID, scope, templateKey string) (MetadataInstance, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+	if scope == "" || templateKey == "" {
+		return nil, errors.New("No metadata scope or templateKey provided")
+	}
+
+	var mi MetadataInstance
+	if err := c.DoJSON("GET", fmt.Sprintf("files/%s/metadata/%s/%s", fileID, scope, templateKey), nil, nil, &mi); err != nil {
+		return nil, err
+	}
+
+	return mi, nil
+}
+
+func (c *Client) FileCreateMetadata(fileID, scope, templateKey string, values map[string]interface{}) (MetadataInstance, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+	if scope == "" || templateKey == "" {
+		return nil, errors.New("No metadata scope or templateKey provided")
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	var mi MetadataInstance
+	if err := c.DoJSON("POST", fmt.Sprintf("files/%s/metadata/%s/%s", fileID, scope, templateKey), nil, values, &mi); err != nil {
+		return nil, err
+	}
+
+	return mi, nil
+}
+
+// MetadataCopyError reports metadata instances that could not be copied to
+// the destination item, e.g. because its enterprise lacks the template.
+type MetadataCopyError struct {
+	Skipped map[string]error // Keyed by "scope/templateKey"
+}
+
+func (e *MetadataCopyError) Error() string {
+	keys := []string{}
+	for k, err := range e.Skipped {
+		keys = append(keys, fmt.Sprintf("%s (%v)", k, err))
+	}
+	return fmt.Sprintf("Skipped copying %d metadata instance(s): %s", len(e.Skipped), strings.Join(keys, ", "))
+}
+
+// fileCopyMetadata applies all of srcFileID's metadata instances to
+// destFileID. Instances whose template doesn't exist for the destination, or
+// that are already present on it, are skipped and reported in a
+// *MetadataCopyError.
+func (c *Client) fileCopyMetadata(srcFileID, destFileID string) error {
+	mis, err := c.FileListMetadata(srcFileID)
+	if err != nil {
+		return err
+	}
+
+	mce := &MetadataCopyError{Skipped: map[string]error{}}
+	for _, mi := range mis {
+		_, err := c.FileCreateMetadata(destFileID, mi.Scope(), mi.TemplateKey(), mi.Values())
+		if err == nil {
+			continue
+		}
+		ae, ok := err.(*APIError)
+		if !ok || !(ae.Status == http.StatusNotFound || ae.Status == http.StatusConflict) {
+			return err
+		}
+		mce.Skipped[mi.Scope()+"/"+mi.TemplateKey()] = err
+	}
+
+	if len(mce.Skipped) > 0 {
+		return mce
+	}
+	return nil
+}
+
+// FileGetClassification returns the name of a file's Box Shield
+// classification, or "" if it isn't classified.
+func (c *Client) FileGetClassification(fileID string) (string, error) {
+	mi, err := c.FileGetMetadata(fileID, "enterprise", ClassificationTemplateKey)
+	if isMetadataInstanceNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	classification, _ := mi[ClassificationField].(string)
+	return classification, nil
+}
+
+// FileSetClassification classifies a file, replacing any classification it
+// already has. classification must be one defined for the enterprise.
+func (c *Client) FileSetClassification(fileID, classification string) error {
+	if classification == "" {
+		return errors.New("No classification provided")
+	}
+
+	_, err := c.FileCreateMetadata(fileID, "enterprise", ClassificationTemplateKey, map[string]interface{}{
+		ClassificationField: classification,
+	})
+	if !isConflict(err) {
+		return err
+	}
+
+	// Already classified: the instance must be updated with a JSON Patch instead
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "replace", "path": "/" + ClassificationField, "value": classification},
+	})
+	if err != nil {
+		return err
+	}
+
+	reqURL, err := c.resolveURL(fmt.Sprintf("files/%s/metadata/enterprise/%s", fileID, ClassificationTemplateKey), nil)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", reqURL, bytes.NewReader(patch))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json-patch+json")
+
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// FileClearClassification removes a file's classification. Clearing an
+// unclassified file is not an error.
+func (c *Client) FileClearClassification(fileID string) error {
+	if fileID == "" {
+		return errors.New("No fileID provided")
+	}
+
+	resp, err := c.Do("DELETE", fmt.Sprintf("files/%s/metadata/enterprise/%s", fileID, ClassificationTemplateKey), nil, nil)
+	if isMetadataInstanceNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// isMetadataInstanceNotFound reports whether err is Box saying the item has no
+// instance of the template, as opposed to the item itself not being found.
+func isMetadataInstanceNotFound(err error) bool {
+	ae, ok := err.(*APIError)
+	return ok && ae.Status == http.StatusNotFound && ae.Code == "instance_not_found"
+}
+
+// Conflict resolutions for MetadataCascadeForceApply: what to do with items
+// that already have an instance of the cascaded template
+var (
+	CascadeConflictResolutionNone      = "none"
+	CascadeConflictResolutionOverwrite = "overwrite"
+)
+
+// CascadePolicy applies a folder's metadata instance to every item in it.
+type CascadePolicy struct {
+	Type            string      `json:"type"`
+	ID              string      `json:"id"`
+	OwnerEnterprise *Enterprise `json:"owner_enterprise,omitempty"`
+	Parent          *FolderItem `json:"parent,omitempty"`
+	Scope           string      `json:"scope"`
+	TemplateKey     string      `json:"templateKey"`
+}
+
+type cascadePoliciesResponse struct {
+	Entries    []*CascadePolicy `json:"entries"`
+	Limit      int              `json:"limit"`
+	NextMarker string           `json:"next_marker"`
+}
+
+type cascadePolicyCreateRequest struct {
+	FolderID    string `json:"folder_id"`
+	Scope       string `json:"scope"`
+	TemplateKey string `json:"templateKey"`
+}
+
+type cascadePolicyApplyRequest struct {
+	ConflictResolution string `json:"conflict_resolution"`
+}
+
+// MetadataCascadeCreate cascades the folder's instance of the given metadata
+// template to all items in the folder, now and in future. The folder must
+// already have an instance of the template.
+func (c *Client) MetadataCascadeCreate(folderID, scope, templateKey string) (*CascadePolicy, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+	if scope == "" || templateKey == "" {
+		return nil, errors.New("No metadata scope or templateKey provided")
+	}
+
+	cpcr := cascadePolicyCreateRequest{
+		FolderID:    folderID,
+		Scope:       scope,
+		TemplateKey: templateKey,
+	}
+
+	var cp CascadePolicy
+	if err := c.DoJSON("POST", "metadata_cascade_policies", nil, &cpcr, &cp); err != nil {
+		return nil, err
+	}
+
+	return &cp, nil
+}
+
+// MetadataCascadeList returns the cascade policies set on a folder.
+func (c *Client) MetadataCascadeList(folderID string) ([]*CascadePolicy, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+
+	cps := []*CascadePolicy{}
+	marker := ""
+
+	// Get all policies, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("folder_id", folderID)
+		parameters.Add("limit", fmt.Sprintf("%d", pageSize(c.PageSizes.CascadePolicies, defaultCascadePoliciesPageSize, maxCascadePoliciesPageSize)))
+		if marker != "" {
+			parameters.Add("marker", marker)
+		}
+
+		var cpr cascadePoliciesResponse
+		if err := c.DoJSON("GET", "metadata_cascade_policies", parameters, nil, &cpr); err != nil {
+			return cps, err
+		}
+
+		cps = append(cps, cpr.Entries...)
+
+		marker = cpr.NextMarker
+		if marker == "" || len(cpr.Entries) == 0 {
+			break
+		}
+	}
+
+	return cps, nil
+}
+
+// MetadataCascadeDelete removes a cascade policy. Metadata already applied to
+// items is left in place.
+func (c *Client) MetadataCascadeDelete(policyID string) error {
+	if policyID == "" {
+		return errors.New("No policyID provided")
+	}
+
+	resp, err := c.Do("DELETE", fmt.Sprintf("metadata_cascade_policies/%s", policyID), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// MetadataCascadeForceApply applies a cascade policy to the items already in
+// its folder. conflictResolution is one of the CascadeConflictResolution*
+// values. Box applies the policy in the background after accepting the
+// request.
+func (c *Client) MetadataCascadeForceApply(policyID, conflictResolution string) error {
+	if policyID == "" {
+		return errors.New("No policyID provided")
+	}
+	if !stringInSlice(conflictResolution, []string{CascadeConflictResolutionNone, CascadeConflictResolutionOverwrite}) {
+		return fmt.Errorf("Invalid conflict resolution: %q", conflictResolution)
+	}
+
+	cpar := cascadePolicyApplyRequest{
+		ConflictResolution: conflictResolution,
+	}
+
+	resp, err := c.Do("POST", fmt.Sprintf("metadata_cascade_policies/%s/apply", policyID), nil, &cpar)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package box
+
+// PageSizes sets the page size each method that fetches every page of a list
+// requests. Larger pages mean fewer round trips, smaller ones a faster first
+// response. 0 means the default; larger sizes are clamped to the endpoint's
+// maximum.
+type PageSizes struct {
+	Users                               int
+	FolderItems                         int
+	TrashItems                          int
+	Collaborations                      int // Collaborations on an item
+	PendingCollaborations               int
+	GroupCollaborations                 int
+	CollaborationWhitelistExemptTargets int
+	Comments                            int
+	Groups                              int
+	FileVersions                        int
+	UploadParts                         int
+	CascadePolicies                     int
+	AdminLogEvents                      int // Events read by UsersRecentLogins
+}
+
+// Default page sizes
+const (
+	defaultUsersPageSize                  = 500
+	defaultFolderItemsPageSize            = 1000
+	defaultTrashItemsPageSize             = 1000
+	defaultCollaborationsPageSize         = 1000
+	defaultPendingCollaborationsPageSize  = 100
+	defaultGroupCollaborationsPageSize    = 1000
+	defaultWhitelistExemptTargetsPageSize = 1000
+	defaultCommentsPageSize               = 1000
+	defaultGroupsPageSize                 = 1000
+	defaultFileVersionsPageSize           = 1000
+	defaultUploadPartsPageSize            = 1000
+	defaultCascadePoliciesPageSize        = 100
+	defaultAdminLogEventsPageSize         = 500
+)
+
+// Maximum page sizes of the endpoints, as documented by Box
+const (
+	maxUsersPageSize                  = 1000
+	maxFolderItemsPageSize            = 1000
+	maxTrashItemsPageSize             = 1000
+	maxCollaborationsPageSize         = 1000
+	maxPendingCollaborationsPageSize  = 100
+	maxGroupCollaborationsPageSize    = 1000
+	maxWhitelistExemptTargetsPageSize = 1000
+	maxCommentsPageSize               = 1000
+	maxGroupsPageSize                 = 1000
+	maxFileVersionsPageSize           = 1000
+	maxUploadPartsPageSize            = 1000
+	maxCascadePoliciesPageSize        = 100
+	maxSearchPageSize                 = 200
+	maxEventsPageSize                 = 500
+)
+
+// pageSize returns a configured page size, def if it's unset, clamped to max.
+func pageSize(size, def, max int) int {
+	if size <= 0 {
+		size = def
+	}
+	if size > max {
+		return max
+	}
+	return size
+}
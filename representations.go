@@ -0,0 +1,425 @@
+package box
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	RepresentationStatusSuccess  = "success"
+	RepresentationStatusViewable = "viewable"
+	RepresentationStatusPending  = "pending"
+	RepresentationStatusNone     = "none"
+	RepresentationStatusError    = "error"
+)
+
+// How often and for how long to poll Box while a representation is generated
+var RepresentationPollInterval = 1 * time.Second
+var RepresentationPollTimeout = 2 * time.Minute
+
+// Dimensions of the page images returned by FileGetPageImage, "1024x1024" or
+// "2048x2048"
+var PageImageDimensions = "1024x1024"
+
+var ErrRepresentationNotSupported = errors.New("Requested representation is not available for this file type")
+
+type FileRepresentationsResponse struct {
+	Type            string `json:"type"`
+	ID              string `json:"id"`
+	Representations struct {
+		Entries []*Representation `json:"entries"`
+	} `json:"representations"`
+}
+
+type Representation struct {
+	Representation string `json:"representation"`
+	Properties     struct {
+		Dimensions string `json:"dimensions"`
+		Paged      string `json:"paged"`
+		Thumb      string `json:"thumb"`
+	} `json:"properties"`
+	Info struct {
+		URL string `json:"url"`
+	} `json:"info"`
+	Status struct {
+		State string `json:"state"`
+	} `json:"status"`
+	Content struct {
+		URLTemplate string `json:"url_template"`
+	} `json:"content"`
+	Metadata struct {
+		Pages int `json:"pages"` // Page count of paged representations
+	} `json:"metadata"`
+}
+
+// FileGetPDF returns a file converted to PDF by Box, waiting up to
+// RepresentationPollTimeout for the conversion. For file types Box can't
+// convert, the error wraps ErrRepresentationNotSupported.
+func (c *Client) FileGetPDF(fileID string) ([]byte, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+
+	reps, err := c.fileGetRepresentations(fileID, "[pdf]")
+	if err != nil {
+		return nil, err
+	}
+	if len(reps) == 0 {
+		return nil, fmt.Errorf("pdf: %w", ErrRepresentationNotSupported)
+	}
+
+	rep, err := c.representationWaitReady(reps[0], RepresentationPollTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.representationDownload(rep, "")
+}
+
+// FileGetExtractedText returns the plain text Box extracted from a file, e.g.
+// for indexing. For file types Box can't extract text from, the error wraps
+// ErrRepresentationNotSupported.
+func (c *Client) FileGetExtractedText(fileID string) (string, error) {
+	if fileID == "" {
+		return "", errors.New("No fileID provided")
+	}
+
+	reps, err := c.fileGetRepresentations(fileID, "[extracted_text]")
+	if err != nil {
+		return "", err
+	}
+	if len(reps) == 0 {
+		return "", fmt.Errorf("extracted_text: %w", ErrRepresentationNotSupported)
+	}
+
+	rep, err := c.representationWaitReady(reps[0], RepresentationPollTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := c.representationDownload(rep, "")
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// FileGetThumbnailSized downloads a jpg or png thumbnail of a file in the
+// given dimensions, e.g. "320x320", waiting for Box to generate it if needed.
+// If Box doesn't offer those dimensions, the error lists the ones it does.
+func (c *Client) FileGetThumbnailSized(fileID, dimensions string) ([]byte, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+	if dimensions == "" {
+		return nil, errors.New("No dimensions provided")
+	}
+
+	reps, err := c.fileGetRepresentations(fileID, fmt.Sprintf("[jpg?dimensions=%s][png?dimensions=%s]", dimensions, dimensions))
+	if err != nil {
+		return nil, err
+	}
+
+	match := thumbnailWithDimensions(reps, dimensions)
+	if match == nil {
+		// List what is offered instead, to help pick valid dimensions
+		all, err := c.fileGetRepresentations(fileID, "")
+		if err != nil {
+			return nil, err
+		}
+		available := []string{}
+		for _, rep := range all {
+			if rep.Representation == "jpg" || rep.Representation == "png" {
+				available = append(available, rep.Representation+" "+rep.Properties.Dimensions)
+			}
+		}
+		if len(available) == 0 {
+			return nil, fmt.Errorf("thumbnail %s: %w", dimensions, ErrRepresentationNotSupported)
+		}
+		return nil, fmt.Errorf("No %s thumbnail offered, available: %s", dimensions, strings.Join(available, ", "))
+	}
+
+	rep, err := c.representationWaitReady(match, RepresentationPollTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Paged representations such as png hold one asset per page
+	assetPath := ""
+	if rep.Properties.Paged == "true" {
+		assetPath = "1." + rep.Representation
+	}
+
+	return c.representationDownload(rep, assetPath)
+}
+
+// FileGetPageCount returns the number of pages of a multi-page document such
+// as a pdf or an office document, waiting for Box to generate its paged
+// representation if needed.
+func (c *Client) FileGetPageCount(fileID string) (int, error) {
+	rep, err := c.filePagedRepresentation(fileID)
+	if err != nil {
+		return 0, err
+	}
+
+	return rep.Metadata.Pages, nil
+}
+
+// FileGetPageImage downloads one page of a multi-page document as a png of
+// PageImageDimensions. Pages are numbered from 1.
+func (c *Client) FileGetPageImage(fileID string, page int) ([]byte, error) {
+	if page < 1 {
+		return nil, fmt.Errorf("Invalid page: %d", page)
+	}
+
+	rep, err := c.filePagedRepresentation(fileID)
+	if err != nil {
+		return nil, err
+	}
+	if page > rep.Metadata.Pages {
+		return nil, fmt.Errorf("Page %d out of range, file has %d pages", page, rep.Metadata.Pages)
+	}
+
+	return c.representationDownload(rep, fmt.Sprintf("%d.png", page))
+}
+
+// filePagedRepresentation returns the ready paged png representation of a
+// file, with its page count.
+func (c *Client) filePagedRepresentation(fileID string) (*Representation, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+
+	hint := fmt.Sprintf("[png?dimensions=%s]", PageImageDimensions)
+	reps, err := c.fileGetRepresentations(fileID, hint)
+	if err != nil {
+		return nil, err
+	}
+	if len(reps) == 0 || reps[0].Properties.Paged != "true" {
+		return nil, fmt.Errorf("paged %s: %w", hint, ErrRepresentationNotSupported)
+	}
+
+	rep, err := c.representationWaitReady(reps[0], RepresentationPollTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// The page count comes with the representation's info, which a
+	// representation that was ready from the start hasn't been polled for
+	if rep.Metadata.Pages == 0 && rep.Info.URL != "" {
+		var info Representation
+		if err := c.DoJSON("GET", rep.Info.URL, nil, nil, &info); err != nil {
+			return nil, err
+		}
+		rep.Metadata.Pages = info.Metadata.Pages
+	}
+	if rep.Metadata.Pages == 0 {
+		return nil, fmt.Errorf("Box returned no page count for %s representation", rep.Representation)
+	}
+
+	return rep, nil
+}
+
+// thumbnailWithDimensions returns the first jpg or png representation of the
+// given dimensions, or nil.
+func thumbnailWithDimensions(reps []*Representation, dimensions string) *Representation {
+	for _, rep := range reps {
+		if (rep.Representation == "jpg" || rep.Representation == "png") && rep.Properties.Dimensions == dimensions {
+			return rep
+		}
+	}
+	return nil
+}
+
+// FileListRepresentations returns every representation Box offers for a
+// file (thumbnails, pdf, extracted_text, mp4, ...) with its current status,
+// so callers can decide which to use. Status.State is one of the
+// RepresentationStatus* values; representations that aren't "success" yet
+// must be generated first (see FileWaitForRepresentation).
+//
+// With repHints, only the matching representations are returned, all in one
+// request, e.g. "jpg?dimensions=1024x1024", "pdf", "extracted_text". Hints may
+// also be given in their bracketed X-Rep-Hints form, "[pdf]".
+func (c *Client) FileListRepresentations(fileID string, repHints ...string) ([]*Representation, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+
+	header := ""
+	for _, hint := range repHints {
+		if !strings.HasPrefix(hint, "[") {
+			hint = "[" + hint + "]"
+		}
+		header += hint
+	}
+
+	return c.fileGetRepresentations(fileID, header)
+}
+
+// FileWaitForRepresentation requests the representation matching repHint
+// (an X-Rep-Hints value such as "[jpg?dimensions=320x320]") and polls until
+// Box has generated it or timeout elapses. If the file has no representation
+// matching repHint, the error wraps ErrRepresentationNotSupported.
+func (c *Client) FileWaitForRepresentation(fileID, repHint string, timeout time.Duration) (*Representation, error) {
+	if fileID == "" {
+		return nil, errors.New("No fileID provided")
+	}
+	if repHint == "" {
+		return nil, errors.New("No repHint provided")
+	}
+
+	reps, err := c.fileGetRepresentations(fileID, repHint)
+	if err != nil {
+		return nil, err
+	}
+	if len(reps) == 0 {
+		return nil, fmt.Errorf("%s: %w", repHint, ErrRepresentationNotSupported)
+	}
+
+	return c.representationWaitReady(reps[0], timeout)
+}
+
+// fileGetRepresentations requests the representations of a file that match
+// the given X-Rep-Hints header value, e.g. "[pdf]" or "[jpg?dimensions=32x32]".
+// With no hints, Box returns all representations available for the file.
+func (c *Client) fileGetRepresentations(fileID, repHints string) ([]*Representation, error) {
+	Url, err := url.Parse(fmt.Sprintf("%s/files/%s", c.APIBaseURL, fileID))
+	if err != nil {
+		return nil, err
+	}
+	parameters := url.Values{}
+	parameters.Add("fields", "representations")
+	Url.RawQuery = parameters.Encode()
+
+	req, err := http.NewRequest("GET", Url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if repHints != "" {
+		req.Header.Set("X-Rep-Hints", repHints)
+	}
+
+	// make request with valid access token
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code while executing API request: %v", resp.Status)
+	}
+
+	var frr FileRepresentationsResponse
+	if err := json.Unmarshal(buf.Bytes(), &frr); err != nil {
+		return nil, err
+	}
+
+	return frr.Representations.Entries, nil
+}
+
+// representationWaitReady polls the representation's info URL until Box
+// reports it as successfully generated, or until timeout elapses.
+func (c *Client) representationWaitReady(rep *Representation, timeout time.Duration) (*Representation, error) {
+	deadline := time.Now().Add(timeout)
+
+	for true {
+		switch rep.Status.State {
+		case RepresentationStatusSuccess:
+			return rep, nil
+		case RepresentationStatusError:
+			return nil, fmt.Errorf("Box failed to generate %s representation", rep.Representation)
+		}
+
+		if rep.Info.URL == "" {
+			return nil, fmt.Errorf("No info URL to poll for %s representation", rep.Representation)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Timed out after %v waiting for %s representation (last state: %s)", timeout, rep.Representation, rep.Status.State)
+		}
+
+		// The first info request also kicks off generation when the state is "none"
+		req, err := http.NewRequest("GET", rep.Info.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.HttpDo(req)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := new(bytes.Buffer)
+		io.Copy(buf, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("Unexpected status code while polling representation: %v", resp.Status)
+		}
+
+		var polled Representation
+		if err := json.Unmarshal(buf.Bytes(), &polled); err != nil {
+			return nil, err
+		}
+		// The info endpoint doesn't repeat the representation name
+		if polled.Representation == "" {
+			polled.Representation = rep.Representation
+		}
+		if polled.Info.URL == "" {
+			polled.Info.URL = rep.Info.URL
+		}
+		rep = &polled
+
+		if rep.Status.State != RepresentationStatusSuccess {
+			time.Sleep(RepresentationPollInterval)
+		}
+	}
+
+	return rep, nil
+}
+
+// representationDownload fetches an asset of a ready representation. Single
+// file representations (pdf, extracted_text) use an empty assetPath, paged
+// ones use the page file name, e.g. "1.png".
+func (c *Client) representationDownload(rep *Representation, assetPath string) ([]byte, error) {
+	if rep.Content.URLTemplate == "" {
+		return nil, fmt.Errorf("No content URL for %s representation", rep.Representation)
+	}
+
+	contentURL := strings.Replace(rep.Content.URLTemplate, "{+asset_path}", assetPath, 1)
+
+	req, err := http.NewRequest("GET", contentURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status code while downloading representation: %v", resp.Status)
+	}
+
+	buf := new(bytes.Buffer)
+	if _, err := copyDownload(buf, resp); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
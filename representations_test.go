@@ -0,0 +1,39 @@
+package box
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newNoRepresentationsClient returns a client whose files have no
+// representations matching any hint, as for file types Box can't convert.
+func newNoRepresentationsClient(t *testing.T) (*Client, func()) {
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/files/9" || r.Header.Get("X-Rep-Hints") == "" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		io.WriteString(w, `{"type":"file","id":"9","representations":{"entries":[]}}`)
+	})
+	return c, done
+}
+
+func TestFileGetPDFNotSupported(t *testing.T) {
+	c, done := newNoRepresentationsClient(t)
+	defer done()
+
+	if _, err := c.FileGetPDF("9"); !errors.Is(err, ErrRepresentationNotSupported) {
+		t.Errorf("err = %v, want ErrRepresentationNotSupported", err)
+	}
+}
+
+func TestFileWaitForRepresentationNotSupported(t *testing.T) {
+	c, done := newNoRepresentationsClient(t)
+	defer done()
+
+	if _, err := c.FileWaitForRepresentation("9", "[jpg?dimensions=320x320]", time.Second); !errors.Is(err, ErrRepresentationNotSupported) {
+		t.Errorf("err = %v, want ErrRepresentationNotSupported", err)
+	}
+}
@@ -0,0 +1,89 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	SearchTrashNonTrashedOnly = "non_trashed_only"
+	SearchTrashTrashedOnly    = "trashed_only"
+	SearchTrashAllItems       = "all_items"
+)
+
+var (
+	SearchContentTypeName        = "name"
+	SearchContentTypeDescription = "description"
+	SearchContentTypeFileContent = "file_content"
+	SearchContentTypeComments    = "comments"
+	SearchContentTypeTags        = "tags"
+)
+
+type SearchOptions struct {
+	Query             string
+	AncestorFolderIDs []string // Only return items within these folders' trees
+	ContentTypes      []string // SearchContentType* values: which parts of an item to match Query against
+	TrashContent      string   // SearchTrash* value; Box defaults to non-trashed items only
+	Type              string   // ItemTypeFile, ItemTypeFolder or ItemTypeWebLink
+	FileExtensions    []string
+	Limit             int // Box allows up to 200
+	Offset            int
+}
+
+type SearchResponse struct {
+	TotalCount int           `json:"total_count"`
+	Entries    []*FolderItem `json:"entries"`
+	Limit      int           `json:"limit"`
+	Offset     int           `json:"offset"`
+}
+
+// Search returns one page of items matching opts. At least a query or one of
+// the filters must be given.
+func (c *Client) Search(opts SearchOptions) (*SearchResponse, error) {
+	if opts.Query == "" && len(opts.AncestorFolderIDs) == 0 && opts.Type == "" && len(opts.FileExtensions) == 0 && opts.TrashContent == "" {
+		return nil, errors.New("No search query or filter provided")
+	}
+	for _, ct := range opts.ContentTypes {
+		if !stringInSlice(ct, []string{SearchContentTypeName, SearchContentTypeDescription, SearchContentTypeFileContent, SearchContentTypeComments, SearchContentTypeTags}) {
+			return nil, fmt.Errorf("Invalid search content type: %q", ct)
+		}
+	}
+	if opts.TrashContent != "" && !stringInSlice(opts.TrashContent, []string{SearchTrashNonTrashedOnly, SearchTrashTrashedOnly, SearchTrashAllItems}) {
+		return nil, fmt.Errorf("Invalid search trash content: %q", opts.TrashContent)
+	}
+
+	parameters := url.Values{}
+	if opts.Query != "" {
+		parameters.Add("query", opts.Query)
+	}
+	if len(opts.AncestorFolderIDs) > 0 {
+		parameters.Add("ancestor_folder_ids", strings.Join(opts.AncestorFolderIDs, ","))
+	}
+	if len(opts.ContentTypes) > 0 {
+		parameters.Add("content_types", strings.Join(opts.ContentTypes, ","))
+	}
+	if opts.TrashContent != "" {
+		parameters.Add("trash_content", opts.TrashContent)
+	}
+	if opts.Type != "" {
+		parameters.Add("type", opts.Type)
+	}
+	if len(opts.FileExtensions) > 0 {
+		parameters.Add("file_extensions", strings.Join(opts.FileExtensions, ","))
+	}
+	if opts.Limit > 0 {
+		parameters.Add("limit", fmt.Sprintf("%d", pageSize(opts.Limit, maxSearchPageSize, maxSearchPageSize)))
+	}
+	if opts.Offset > 0 {
+		parameters.Add("offset", fmt.Sprintf("%d", opts.Offset))
+	}
+
+	var sr SearchResponse
+	if err := c.DoJSON("GET", "search", parameters, nil, &sr); err != nil {
+		return nil, err
+	}
+
+	return &sr, nil
+}
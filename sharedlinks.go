@@ -0,0 +1,207 @@
+package box
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+var (
+	SharedLinkAccessOpen          = "open"
+	SharedLinkAccessCompany       = "company"
+	SharedLinkAccessCollaborators = "collaborators"
+)
+
+type SharedLink struct {
+	URL                 string                 `json:"url,omitempty"`
+	DownloadURL         string                 `json:"download_url,omitempty"`
+	VanityURL           string                 `json:"vanity_url,omitempty"`
+	Access              string                 `json:"access,omitempty"`
+	EffectiveAccess     string                 `json:"effective_access,omitempty"`
+	EffectivePermission string                 `json:"effective_permission,omitempty"`
+	UnsharedAt          string                 `json:"unshared_at,omitempty"`
+	IsPasswordEnabled   bool                   `json:"is_password_enabled,omitempty"`
+	DownloadCount       int                    `json:"download_count,omitempty"`
+	PreviewCount        int                    `json:"preview_count,omitempty"`
+	Permissions         *SharedLinkPermissions `json:"permissions,omitempty"`
+}
+
+type SharedLinkPermissions struct {
+	CanDownload *bool `json:"can_download,omitempty"`
+	CanPreview  *bool `json:"can_preview,omitempty"`
+	CanEdit     *bool `json:"can_edit,omitempty"`
+}
+
+// SharedLinkOptions configures a shared link. Empty fields are left to Box's
+// defaults (or unchanged, when updating). CanDownload and CanPreview are
+// pointers so "false" can be told apart from "not set", e.g. set CanDownload
+// to false for a view-only link.
+type SharedLinkOptions struct {
+	Access      string // SharedLinkAccess* value
+	Password    string
+	UnsharedAt  string // RFC 3339 expiry time
+	VanityName  string
+	CanDownload *bool
+	CanPreview  *bool
+}
+
+type sharedLinkRequest struct {
+	SharedLink sharedLinkRequestBody `json:"shared_link"`
+}
+
+type sharedLinkRequestBody struct {
+	Access      string                 `json:"access,omitempty"`
+	Password    string                 `json:"password,omitempty"`
+	UnsharedAt  string                 `json:"unshared_at,omitempty"`
+	VanityName  string                 `json:"vanity_name,omitempty"`
+	Permissions *SharedLinkPermissions `json:"permissions,omitempty"`
+}
+
+type sharedLinkResponse struct {
+	SharedLink *SharedLink `json:"shared_link"`
+}
+
+// FileCreateSharedLink creates a shared link for a file, or replaces the
+// settings of its existing one, so it also serves to update a link.
+func (c *Client) FileCreateSharedLink(fileID string, opts *SharedLinkOptions) (*SharedLink, error) {
+	return c.itemSetSharedLink("files", fileID, opts)
+}
+
+// FolderCreateSharedLink creates a shared link for a folder, or replaces the
+// settings of its existing one.
+func (c *Client) FolderCreateSharedLink(folderID string, opts *SharedLinkOptions) (*SharedLink, error) {
+	return c.itemSetSharedLink("folders", folderID, opts)
+}
+
+// itemSetSharedLink sets the shared link of the item at
+// <collection>/<itemID>; Box uses the same request to create and update it.
+func (c *Client) itemSetSharedLink(collection, itemID string, opts *SharedLinkOptions) (*SharedLink, error) {
+	if itemID == "" {
+		return nil, errors.New("No itemID provided")
+	}
+	if opts == nil {
+		opts = &SharedLinkOptions{}
+	}
+	if opts.Access != "" && !stringInSlice(opts.Access, []string{SharedLinkAccessOpen, SharedLinkAccessCompany, SharedLinkAccessCollaborators}) {
+		return nil, fmt.Errorf("Invalid shared link access: %q", opts.Access)
+	}
+
+	slr := sharedLinkRequest{
+		SharedLink: sharedLinkRequestBody{
+			Access:     opts.Access,
+			Password:   opts.Password,
+			UnsharedAt: opts.UnsharedAt,
+			VanityName: opts.VanityName,
+		},
+	}
+	if opts.CanDownload != nil || opts.CanPreview != nil {
+		slr.SharedLink.Permissions = &SharedLinkPermissions{
+			CanDownload: opts.CanDownload,
+			CanPreview:  opts.CanPreview,
+		}
+	}
+
+	parameters := url.Values{}
+	parameters.Add("fields", "shared_link")
+
+	var sl sharedLinkResponse
+	if err := c.DoJSON("PUT", fmt.Sprintf("%s/%s", collection, itemID), parameters, &slr, &sl); err != nil {
+		return nil, err
+	}
+	if sl.SharedLink == nil {
+		return nil, errors.New("Box returned no shared link")
+	}
+
+	return sl.SharedLink, nil
+}
+
+// boxAPISharedLinkHeader builds the BoxApi header value that grants access to
+// an item through its shared link.
+func boxAPISharedLinkHeader(sharedURL, password string) string {
+	h := "shared_link=" + sharedURL
+	if password != "" {
+		h += "&shared_link_password=" + url.QueryEscape(password)
+	}
+	return h
+}
+
+// GetSharedItem resolves a shared link to the file or folder it points to
+// (type, ID, name, size and so on) without downloading anything, e.g. to
+// decide whether to download or list it. password is only needed for
+// password protected links; if it's missing or wrong the error matches
+// ErrSharedLinkPasswordRequired.
+func (c *Client) GetSharedItem(sharedURL, password string) (*FolderItem, error) {
+	if sharedURL == "" {
+		return nil, errors.New("No sharedURL provided")
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/shared_items", c.APIBaseURL))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", Url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("BoxApi", boxAPISharedLinkHeader(sharedURL, password))
+
+	// make request with valid access token
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	var fi FolderItem
+	if err := json.Unmarshal(buf.Bytes(), &fi); err != nil {
+		return nil, err
+	}
+
+	return &fi, nil
+}
+
+// FileDownloadBySharedLink downloads the file a shared link points to, like
+// FileDownload. password is only needed for password protected links; if it's
+// missing or wrong the error matches ErrSharedLinkPasswordRequired.
+func (c *Client) FileDownloadBySharedLink(sharedURL, password string) (*http.Response, error) {
+	fi, err := c.GetSharedItem(sharedURL, password)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Type != ItemTypeFile {
+		return nil, fmt.Errorf("Shared link points to a %s, not a file", fi.Type)
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/files/%s/content", c.APIBaseURL, fi.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", Url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("BoxApi", boxAPISharedLinkHeader(sharedURL, password))
+
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, newAPIError(resp)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,173 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// FileGetTrashedInfo returns a trashed file as it exists in the trash,
+// including when it was trashed (TrashedAt) and will be purged (PurgedAt).
+func (c *Client) FileGetTrashedInfo(boxFileID string) (*FileEntry, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+
+	var fe FileEntry
+	if err := c.DoJSON("GET", fmt.Sprintf("files/%s/trash", boxFileID), nil, nil, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+// FolderGetTrashedInfo is the folder equivalent of FileGetTrashedInfo.
+func (c *Client) FolderGetTrashedInfo(folderID string) (*FolderEntry, error) {
+	if folderID == "" {
+		return nil, errors.New("No folderID provided")
+	}
+
+	var fe FolderEntry
+	if err := c.DoJSON("GET", fmt.Sprintf("folders/%s/trash", folderID), nil, nil, &fe); err != nil {
+		return nil, err
+	}
+
+	return &fe, nil
+}
+
+type itemRestoreRequest struct {
+	Name   string                   `json:"name,omitempty"`
+	Parent *FileUploadRequestParent `json:"parent,omitempty"`
+}
+
+// FileRestore restores a trashed file. By default it goes back under its old
+// name into its original folder; newName and newParentID override either.
+// If the name is taken there and renameOnConflict is set, the file is
+// restored under a numbered name instead, e.g. "a (1).txt".
+func (c *Client) FileRestore(boxFileID, newName, newParentID string, renameOnConflict bool) (*FileEntry, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+	if newName != "" {
+		if err := ValidateItemName(newName); err != nil {
+			return nil, err
+		}
+	}
+
+	restore := func(name string) (*FileEntry, error) {
+		irr := itemRestoreRequest{Name: name}
+		if newParentID != "" {
+			irr.Parent = &FileUploadRequestParent{ID: newParentID}
+		}
+
+		var fe FileEntry
+		if err := c.DoJSON("POST", fmt.Sprintf("files/%s", boxFileID), nil, &irr, &fe); err != nil {
+			return nil, err
+		}
+		return &fe, nil
+	}
+
+	fe, err := restore(newName)
+	if !renameOnConflict || !isConflict(err) {
+		return fe, err
+	}
+
+	// Derive alternatives from the name the file would have been restored as
+	name := newName
+	if name == "" {
+		trashed, err := c.FileGetTrashedInfo(boxFileID)
+		if err != nil {
+			return nil, err
+		}
+		name = trashed.Name
+	}
+
+	for n := 1; n <= MaxConflictRenames; n++ {
+		fe, err = restore(conflictName(name, n, true))
+		if !isConflict(err) {
+			return fe, err
+		}
+	}
+
+	return nil, err
+}
+
+// TrashListItems returns every item in the trash of the current user (or of
+// the user the client acts as).
+func (c *Client) TrashListItems() ([]*FolderItem, error) {
+	fis := []*FolderItem{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.TrashItems, defaultTrashItemsPageSize, maxTrashItemsPageSize)
+
+	// Get all items, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("fields", "type,id,sequence_id,etag,name,size,sha1")
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var fir FolderItemsResponse
+		if err := c.DoJSON("GET", "folders/trash/items", parameters, nil, &fir); err != nil {
+			return fis, err
+		}
+
+		fis = append(fis, fir.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = fir.Offset + fir.Limit
+
+		if offset >= fir.TotalCount || len(fir.Entries) == 0 {
+			break
+		}
+	}
+
+	return fis, nil
+}
+
+// TrashEmpty permanently deletes everything in the trash, running up to
+// concurrency deletions at once, each retried while Box rate limits it or is
+// unavailable. The result holds an entry for every item found, nil on
+// success. The trash is listed in full before anything is deleted, so items
+// trashed meanwhile are left alone.
+func (c *Client) TrashEmpty(concurrency int) (map[ItemRef]error, error) {
+	fis, err := c.TrashListItems()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[ItemRef]error, len(fis))
+	)
+	forEachConcurrent(len(fis), concurrency, func(i int) {
+		err := withRateLimitRetry(func() error {
+			return c.trashPermanentDelete(fis[i].Type, fis[i].ID)
+		})
+		mu.Lock()
+		results[ItemRef{Type: fis[i].Type, ID: fis[i].ID}] = err
+		mu.Unlock()
+	})
+
+	return results, nil
+}
+
+// trashPermanentDelete removes a trashed item for good. itemType is
+// ItemTypeFile, ItemTypeFolder or ItemTypeWebLink.
+func (c *Client) trashPermanentDelete(itemType, itemID string) error {
+	if !stringInSlice(itemType, []string{ItemTypeFile, ItemTypeFolder, ItemTypeWebLink}) {
+		return fmt.Errorf("Invalid itemType: %q", itemType)
+	}
+	if itemID == "" {
+		return errors.New("No itemID provided")
+	}
+
+	resp, err := c.Do("DELETE", fmt.Sprintf("%ss/%s/trash", itemType, itemID), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package box
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrashEmpty(t *testing.T) {
+	origRateLimit := DefaultRateLimitRetryAfter
+	DefaultRateLimitRetryAfter = time.Millisecond
+	defer func() { DefaultRateLimitRetryAfter = origRateLimit }()
+
+	var (
+		mu    sync.Mutex
+		calls = map[string]int{}
+	)
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.Method+" "+r.URL.Path]++
+		n := calls[r.Method+" "+r.URL.Path]
+		mu.Unlock()
+		switch r.Method + " " + r.URL.Path {
+		case "GET /folders/trash/items":
+			io.WriteString(w, `{"total_count":2,"offset":0,"limit":1000,"entries":[{"type":"file","id":"3"},{"type":"folder","id":"3"}]}`)
+		case "DELETE /files/3/trash":
+			if n == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "DELETE /folders/3/trash":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+
+	results, err := c.TrashEmpty(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file and folder share an ID, so each needs its own entry
+	file, folder := ItemRef{Type: ItemTypeFile, ID: "3"}, ItemRef{Type: ItemTypeFolder, ID: "3"}
+	if len(results) != 2 {
+		t.Errorf("results = %v, want entries for %+v and %+v", results, file, folder)
+	}
+	for _, item := range []ItemRef{file, folder} {
+		if err, ok := results[item]; !ok || err != nil {
+			t.Errorf("%+v: err = %v, present %v; want a successful purge", item, err, ok)
+		}
+	}
+	if calls["DELETE /files/3/trash"] != 2 {
+		t.Errorf("rate limited file purged %d times, want 2", calls["DELETE /files/3/trash"])
+	}
+}
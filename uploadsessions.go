@@ -0,0 +1,427 @@
+package box
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// How long to keep retrying while Box reports (202) that it is still
+// processing the parts of a committed upload session
+var UploadSessionCommitTimeout = 2 * time.Minute
+
+// UploadSession is a chunked upload in progress. Box decides PartSize; every
+// part but the last must be exactly that size.
+type UploadSession struct {
+	Type              string `json:"type"`
+	ID                string `json:"id"`
+	SessionExpiresAt  string `json:"session_expires_at"`
+	PartSize          int64  `json:"part_size"`
+	TotalParts        int    `json:"total_parts"`
+	NumPartsProcessed int    `json:"num_parts_processed"`
+	SessionEndpoints  struct {
+		UploadPart string `json:"upload_part"`
+		Commit     string `json:"commit"`
+		Abort      string `json:"abort"`
+		ListParts  string `json:"list_parts"`
+		Status     string `json:"status"`
+		LogEvent   string `json:"log_event"`
+	} `json:"session_endpoints"`
+}
+
+// UploadPart is a part of an upload session that Box has received.
+type UploadPart struct {
+	PartID string `json:"part_id"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	Sha1   string `json:"sha1"`
+}
+
+type uploadSessionCreateRequest struct {
+	FolderID string `json:"folder_id,omitempty"`
+	FileSize int64  `json:"file_size"`
+	FileName string `json:"file_name,omitempty"`
+}
+
+type uploadPartsResponse struct {
+	TotalCount int          `json:"total_count"`
+	Entries    []UploadPart `json:"entries"`
+	Limit      int          `json:"limit"`
+	Offset     int          `json:"offset"`
+}
+
+type uploadPartResponse struct {
+	Part UploadPart `json:"part"`
+}
+
+type uploadSessionCommitRequest struct {
+	Parts []UploadPart `json:"parts"`
+}
+
+// CreateUploadSession starts a chunked upload of a new file of the given
+// size. Box only accepts sessions for files of 20MB or more.
+func (c *Client) CreateUploadSession(boxFolderID, name string, size int64) (*UploadSession, error) {
+	if boxFolderID == "" {
+		return nil, errors.New("No boxFolderID provided")
+	}
+	if err := ValidateItemName(name); err != nil {
+		return nil, err
+	}
+
+	uscr := uploadSessionCreateRequest{
+		FolderID: boxFolderID,
+		FileSize: size,
+		FileName: name,
+	}
+
+	var us UploadSession
+	if err := c.DoJSON("POST", fmt.Sprintf("%s/files/upload_sessions", c.UploadBaseURL), nil, &uscr, &us); err != nil {
+		return nil, err
+	}
+
+	return &us, nil
+}
+
+// CreateVersionUploadSession starts a chunked upload of a new version of an
+// existing file.
+func (c *Client) CreateVersionUploadSession(boxFileID string, size int64) (*UploadSession, error) {
+	if boxFileID == "" {
+		return nil, errors.New("No boxFileID provided")
+	}
+
+	uscr := uploadSessionCreateRequest{
+		FileSize: size,
+	}
+
+	var us UploadSession
+	if err := c.DoJSON("POST", fmt.Sprintf("%s/files/%s/upload_sessions", c.UploadBaseURL, boxFileID), nil, &uscr, &us); err != nil {
+		return nil, err
+	}
+
+	return &us, nil
+}
+
+// GetUploadSession returns the current state of an upload session.
+func (c *Client) GetUploadSession(sessionID string) (*UploadSession, error) {
+	if sessionID == "" {
+		return nil, errors.New("No sessionID provided")
+	}
+
+	var us UploadSession
+	if err := c.DoJSON("GET", fmt.Sprintf("%s/files/upload_sessions/%s", c.UploadBaseURL, sessionID), nil, nil, &us); err != nil {
+		return nil, err
+	}
+
+	return &us, nil
+}
+
+// ListUploadSessionParts returns the parts Box has received so far for an
+// upload session, e.g. to find out what's left to upload after a failure.
+func (c *Client) ListUploadSessionParts(sessionID string) ([]UploadPart, error) {
+	if sessionID == "" {
+		return nil, errors.New("No sessionID provided")
+	}
+
+	ups := []UploadPart{}
+
+	offset := 0
+	limit := pageSize(c.PageSizes.UploadParts, defaultUploadPartsPageSize, maxUploadPartsPageSize)
+
+	// Get all parts, looping through API pages
+	for true {
+		parameters := url.Values{}
+		parameters.Add("offset", fmt.Sprintf("%d", offset))
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+
+		var upr uploadPartsResponse
+		if err := c.DoJSON("GET", fmt.Sprintf("%s/files/upload_sessions/%s/parts", c.UploadBaseURL, sessionID), parameters, nil, &upr); err != nil {
+			return ups, err
+		}
+
+		ups = append(ups, upr.Entries...)
+
+		// Use the values returned by the API response, not values passed in request
+		offset = upr.Offset + upr.Limit
+
+		if offset >= upr.TotalCount || len(upr.Entries) == 0 {
+			break
+		}
+	}
+
+	return ups, nil
+}
+
+// AbortUploadSession discards an upload session and the parts uploaded to it,
+// freeing the space reserved for it.
+func (c *Client) AbortUploadSession(sessionID string) error {
+	if sessionID == "" {
+		return errors.New("No sessionID provided")
+	}
+
+	resp, err := c.Do("DELETE", fmt.Sprintf("%s/files/upload_sessions/%s", c.UploadBaseURL, sessionID), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}
+
+// FileUploadChunkedFromPath uploads a large file into boxFolderID through an
+// upload session, one part at a time. To resume an interrupted upload, pass
+// the session ID returned with the error as resumeSessionID: parts Box
+// already has are then skipped. The file must not change in between.
+//
+// Only transient failures (network errors, rate limiting, Box server errors)
+// leave the session open for resuming. On any other error the session is
+// aborted and the returned session ID is empty; if aborting fails too, the
+// session ID is returned so it can be aborted later.
+func (c *Client) FileUploadChunkedFromPath(localFilepath, boxFolderID, resumeSessionID string) (*FileUploadResponse, string, error) {
+	return c.fileUploadChunked(localFilepath, resumeSessionID, func(name string, size int64) (*UploadSession, error) {
+		return c.CreateUploadSession(boxFolderID, name, size)
+	})
+}
+
+// FileUploadVersionChunkedFromPath is the FileUploadChunkedFromPath
+// equivalent of FileUploadVersionFromPath.
+func (c *Client) FileUploadVersionChunkedFromPath(localFilepath, boxFileID, resumeSessionID string) (*FileUploadResponse, string, error) {
+	return c.fileUploadChunked(localFilepath, resumeSessionID, func(name string, size int64) (*UploadSession, error) {
+		return c.CreateVersionUploadSession(boxFileID, size)
+	})
+}
+
+// fileUploadChunked uploads a local file through the upload session
+// resumeSessionID or, if that's empty, through a new one from createSession.
+func (c *Client) fileUploadChunked(localFilepath, resumeSessionID string, createSession func(name string, size int64) (*UploadSession, error)) (*FileUploadResponse, string, error) {
+	f, err := os.Open(localFilepath)
+	if err != nil {
+		return nil, resumeSessionID, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, resumeSessionID, err
+	}
+	size := fi.Size()
+
+	var us *UploadSession
+	uploaded := map[int64]UploadPart{}
+	if resumeSessionID == "" {
+		us, err = createSession(filepath.Base(localFilepath), size)
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		us, err = c.GetUploadSession(resumeSessionID)
+		if err != nil {
+			return nil, resumeSessionID, err
+		}
+		ups, err := c.ListUploadSessionParts(us.ID)
+		if err != nil {
+			return nil, us.ID, err
+		}
+		for _, up := range ups {
+			uploaded[up.Offset] = up
+		}
+	}
+
+	// fail aborts the session, unless err leaves a chance of resuming it
+	fail := func(err error) (*FileUploadResponse, string, error) {
+		if isTransient(err) {
+			return nil, us.ID, err
+		}
+		if abortErr := c.AbortUploadSession(us.ID); abortErr != nil {
+			return nil, us.ID, fmt.Errorf("%w (aborting upload session %s also failed: %v)", err, us.ID, abortErr)
+		}
+		return nil, "", err
+	}
+
+	if us.PartSize <= 0 {
+		return fail(fmt.Errorf("Invalid upload session part size: %d", us.PartSize))
+	}
+
+	// Every part is read, uploaded or not, since the commit needs the digest
+	// of the whole file
+	fileHash := sha1.New()
+	parts := []UploadPart{}
+	buf := make([]byte, us.PartSize)
+	for offset := int64(0); offset < size; offset += us.PartSize {
+		part := buf[:minInt64(us.PartSize, size-offset)]
+		if n, err := f.ReadAt(part, offset); n < len(part) {
+			return fail(fmt.Errorf("Reading part at offset %d: %v", offset, err))
+		}
+		fileHash.Write(part)
+		partHash := sha1.Sum(part)
+
+		if up, ok := uploaded[offset]; ok && up.Size == int64(len(part)) {
+			if up.Sha1 != "" && up.Sha1 != hex.EncodeToString(partHash[:]) {
+				return fail(fmt.Errorf("Local file differs from the part uploaded at offset %d", offset))
+			}
+			parts = append(parts, up)
+			continue
+		}
+
+		up, err := c.uploadSessionPart(us.ID, part, partHash[:], offset, size)
+		if err != nil {
+			return fail(err)
+		}
+		parts = append(parts, *up)
+	}
+
+	fur, err := c.commitUploadSession(us.ID, parts, fileHash.Sum(nil))
+	if _, ok := err.(*APIError); ok {
+		return fail(err)
+	} else if err != nil {
+		// e.g. timed out while Box is still processing: the commit can be retried
+		return nil, us.ID, err
+	}
+
+	return fur, us.ID, nil
+}
+
+// uploadSessionPart uploads the part of a file of totalSize bytes that starts
+// at offset. sha1Sum is the raw SHA-1 of part.
+func (c *Client) uploadSessionPart(sessionID string, part, sha1Sum []byte, offset, totalSize int64) (*UploadPart, error) {
+	Url, err := url.Parse(fmt.Sprintf("%s/files/upload_sessions/%s", c.UploadBaseURL, sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", Url.String(), bytes.NewReader(part))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(part))-1, totalSize))
+	req.Header.Set("Digest", "sha="+base64.StdEncoding.EncodeToString(sha1Sum))
+
+	// make request with valid access token
+	resp, err := c.HttpDo(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	// Read the response body
+	buf := new(bytes.Buffer)
+	io.Copy(buf, resp.Body)
+	resp.Body.Close()
+
+	var upr uploadPartResponse
+	if err := json.Unmarshal(buf.Bytes(), &upr); err != nil {
+		return nil, err
+	}
+
+	return &upr.Part, nil
+}
+
+// CommitUploadSession assembles the uploaded parts, in order, into the file
+// and returns it, waiting while Box is still processing the parts. sha1Hex is
+// the SHA1 of the whole file, as returned by FileSHA1.
+func (c *Client) CommitUploadSession(sessionID string, parts []UploadPart, sha1Hex string) (*FileEntry, error) {
+	if sessionID == "" {
+		return nil, errors.New("No sessionID provided")
+	}
+	sha1Sum, err := hex.DecodeString(sha1Hex)
+	if err != nil || len(sha1Sum) != sha1.Size {
+		return nil, fmt.Errorf("Invalid SHA1: %q", sha1Hex)
+	}
+
+	fur, err := c.commitUploadSession(sessionID, parts, sha1Sum)
+	if err != nil {
+		return nil, err
+	}
+
+	return fur.Entries[0], nil
+}
+
+// commitUploadSession assembles the uploaded parts into the file. sha1Sum is
+// the raw SHA-1 of the whole file.
+func (c *Client) commitUploadSession(sessionID string, parts []UploadPart, sha1Sum []byte) (*FileUploadResponse, error) {
+	body, err := json.Marshal(uploadSessionCommitRequest{Parts: parts})
+	if err != nil {
+		return nil, err
+	}
+
+	Url, err := url.Parse(fmt.Sprintf("%s/files/upload_sessions/%s/commit", c.UploadBaseURL, sessionID))
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(UploadSessionCommitTimeout)
+
+	for true {
+		req, err := http.NewRequest("POST", Url.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Digest", "sha="+base64.StdEncoding.EncodeToString(sha1Sum))
+
+		// make request with valid access token
+		resp, err := c.HttpDo(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Box is still processing the parts, try again when it says to
+		if resp.StatusCode == http.StatusAccepted {
+			resp.Body.Close()
+			if time.Now().After(deadline) {
+				return nil, fmt.Errorf("Timed out after %v waiting for upload session %s to commit", UploadSessionCommitTimeout, sessionID)
+			}
+			time.Sleep(retryAfter(resp, 1*time.Second))
+			continue
+		}
+		if resp.StatusCode != http.StatusCreated {
+			return nil, newAPIError(resp)
+		}
+
+		// Read the response body
+		buf := new(bytes.Buffer)
+		io.Copy(buf, resp.Body)
+		resp.Body.Close()
+
+		var fur FileUploadResponse
+		if err := json.Unmarshal(buf.Bytes(), &fur); err != nil {
+			return nil, err
+		}
+		fur.Status = resp.StatusCode
+		if len(fur.Entries) == 0 {
+			return nil, fmt.Errorf("Box returned no file entry for committed upload session %s. Body: %v", sessionID, buf.String())
+		}
+
+		return &fur, nil
+	}
+
+	return nil, nil
+}
+
+// isTransient reports whether a failed request might succeed if retried:
+// transport errors, rate limiting, Box server errors and an open circuit
+// breaker. Errors that aren't from a request at all are not transient.
+func isTransient(err error) bool {
+	if err == ErrCircuitOpen {
+		return true
+	}
+	switch e := err.(type) {
+	case *APIError:
+		return e.Status == http.StatusTooManyRequests || e.Status >= http.StatusInternalServerError
+	case *url.Error:
+		return true
+	}
+	return false
+}
@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 )
 
 var (
@@ -17,6 +19,13 @@ var (
 	UserStatusCannotDeleteEditUpload = "cannot_delete_edit_upload"
 )
 
+// What a user matched on in UsersSearchAllAnnotated
+var (
+	UserMatchName       = "name"
+	UserMatchLogin      = "login"
+	UserMatchEmailAlias = "email_alias"
+)
+
 type UsersResponse struct {
 	TotalCount int          `json:"total_count"`
 	Entries    []*UserEntry `json:"entries"`
@@ -25,22 +34,46 @@ type UsersResponse struct {
 }
 
 type UserEntry struct {
-	Type          string  `json:"type,omitempty"`
-	ID            string  `json:"id,omitempty"`
-	Name          string  `json:"name,omitempty"`
-	Login         string  `json:"login,omitempty"`
-	CreatedAt     string  `json:"created_at,omitempty"`
-	ModifiedAt    string  `json:"modified_at,omitempty"`
-	Language      string  `json:"language,omitempty"`
-	Timezone      string  `json:"timezone,omitempty"`
-	SpaceAmount   float64 `json:"space_amount,omitempty"`
-	SpaceUsed     float64 `json:"space_used,omitempty"`
-	MaxUploadSize float64 `json:"max_upload_size,omitempty"`
-	Status        string  `json:"status,omitempty"`
-	JobTitle      string  `json:"job_title,omitempty"`
-	Phone         string  `json:"phone,omitempty"`
-	Address       string  `json:"address,omitempty"`
-	AvatarURL     string  `json:"avatar_url,omitempty"`
+	Type          string      `json:"type,omitempty"`
+	ID            string      `json:"id,omitempty"`
+	Name          string      `json:"name,omitempty"`
+	Login         string      `json:"login,omitempty"`
+	CreatedAt     string      `json:"created_at,omitempty"`
+	ModifiedAt    string      `json:"modified_at,omitempty"`
+	Language      string      `json:"language,omitempty"`
+	Timezone      string      `json:"timezone,omitempty"`
+	SpaceAmount   int64       `json:"space_amount,omitempty"`
+	SpaceUsed     int64       `json:"space_used,omitempty"`
+	MaxUploadSize int64       `json:"max_upload_size,omitempty"`
+	Status        string      `json:"status,omitempty"`
+	JobTitle      string      `json:"job_title,omitempty"`
+	Phone         string      `json:"phone,omitempty"`
+	Address       string      `json:"address,omitempty"`
+	AvatarURL     string      `json:"avatar_url,omitempty"`
+	Enterprise    *Enterprise `json:"enterprise,omitempty"`
+
+	IsPlatformAccessOnly bool `json:"is_platform_access_only,omitempty"` // App users; can only be set when creating the user
+}
+
+type Enterprise struct {
+	Type string `json:"type,omitempty"`
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// EnterpriseGet returns the enterprise of the user the client acts as, e.g.
+// for checking a tool is pointed at the right tenant. Box only exposes its
+// type, ID and name this way; enterprise settings aren't available through
+// the API.
+func (c *Client) EnterpriseGet() (*Enterprise, error) {
+	ue, err := c.UsersGetCurrent("enterprise")
+	if err != nil {
+		return nil, err
+	}
+	if ue.Enterprise == nil {
+		return nil, errors.New("Current user does not belong to an enterprise")
+	}
+	return ue.Enterprise, nil
 }
 
 func (c *Client) UsersSearchAll(filterTerm string) ([]*UserEntry, error) {
@@ -50,7 +83,7 @@ func (c *Client) UsersSearchAll(filterTerm string) ([]*UserEntry, error) {
 	ues := []*UserEntry{}
 
 	offset := 0
-	limit := 500
+	limit := pageSize(c.PageSizes.Users, defaultUsersPageSize, maxUsersPageSize)
 
 	// Get all users, looping through API pages
 	for true {
@@ -108,6 +141,127 @@ func (c *Client) UsersSearchAll(filterTerm string) ([]*UserEntry, error) {
 	return ues, nil
 }
 
+// UserSearchResult is a user found by UsersSearchAllAnnotated, with what it
+// matched on.
+type UserSearchResult struct {
+	User      *UserEntry
+	MatchedOn []string // UserMatch* values
+}
+
+type EmailAlias struct {
+	Type        string `json:"type,omitempty"`
+	ID          string `json:"id,omitempty"`
+	Email       string `json:"email,omitempty"`
+	IsConfirmed bool   `json:"is_confirmed,omitempty"`
+}
+
+type emailAliasesResponse struct {
+	TotalCount int           `json:"total_count"`
+	Entries    []*EmailAlias `json:"entries"`
+}
+
+// UsersGetEmailAliases returns the email aliases of a user, which don't
+// include their primary login.
+func (c *Client) UsersGetEmailAliases(userID string) ([]*EmailAlias, error) {
+	if userID == "" {
+		return nil, errors.New("No userID provided")
+	}
+
+	var ear emailAliasesResponse
+	if err := c.DoJSON("GET", fmt.Sprintf("users/%s/email_aliases", userID), nil, nil, &ear); err != nil {
+		return nil, err
+	}
+
+	return ear.Entries, nil
+}
+
+// UsersSearchAllAnnotated is like UsersSearchAll, but says for each user
+// whether filterTerm matched the start of their name (or of a word in it),
+// their login, or an email alias. Box's filter_term never searches email
+// aliases, so alias matches are only found when aliasConcurrency is above 0:
+// then every user in the enterprise is listed and their aliases fetched, up
+// to aliasConcurrency at once, which takes one request per user.
+func (c *Client) UsersSearchAllAnnotated(filterTerm string, aliasConcurrency int) ([]*UserSearchResult, error) {
+	if filterTerm == "" {
+		return nil, errors.New("No filterTerm provided")
+	}
+	term := strings.ToLower(filterTerm)
+
+	ues, err := c.UsersSearchAll(filterTerm)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*UserSearchResult{}
+	found := map[string]*UserSearchResult{}
+	for _, ue := range ues {
+		usr := &UserSearchResult{User: ue, MatchedOn: []string{}}
+		if userNameMatches(ue.Name, term) {
+			usr.MatchedOn = append(usr.MatchedOn, UserMatchName)
+		}
+		if strings.HasPrefix(strings.ToLower(ue.Login), term) {
+			usr.MatchedOn = append(usr.MatchedOn, UserMatchLogin)
+		}
+		results = append(results, usr)
+		found[ue.ID] = usr
+	}
+
+	if aliasConcurrency < 1 {
+		return results, nil
+	}
+
+	all, err := c.UsersGetAll()
+	if err != nil {
+		return results, err
+	}
+
+	var (
+		mu       sync.Mutex
+		aliasErr error
+	)
+	forEachConcurrent(len(all), aliasConcurrency, func(i int) {
+		aliases, err := c.UsersGetEmailAliases(all[i].ID)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if aliasErr == nil {
+				aliasErr = fmt.Errorf("Error getting email aliases of user %s: %w", all[i].ID, err)
+			}
+			return
+		}
+		for _, alias := range aliases {
+			if !strings.HasPrefix(strings.ToLower(alias.Email), term) {
+				continue
+			}
+			usr, ok := found[all[i].ID]
+			if !ok {
+				usr = &UserSearchResult{User: all[i], MatchedOn: []string{}}
+				results = append(results, usr)
+				found[all[i].ID] = usr
+			}
+			usr.MatchedOn = append(usr.MatchedOn, UserMatchEmailAlias)
+			break
+		}
+	})
+
+	return results, aliasErr
+}
+
+// userNameMatches reports whether the lowercase term is a prefix of name or
+// of any word in it, as Box matches names.
+func userNameMatches(name, term string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, term) {
+		return true
+	}
+	for _, word := range strings.Fields(name) {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Client) UsersGetAll() ([]*UserEntry, error) {
 	// TODO: add method paramter for field list
 	// TODO: add method paramter for user_type
@@ -115,7 +269,7 @@ func (c *Client) UsersGetAll() ([]*UserEntry, error) {
 	ues := []*UserEntry{}
 
 	offset := 0
-	limit := 500
+	limit := pageSize(c.PageSizes.Users, defaultUsersPageSize, maxUsersPageSize)
 
 	// Get all users, looping through API pages
 	for true {
@@ -172,18 +326,25 @@ func (c *Client) UsersGetAll() ([]*UserEntry, error) {
 	return ues, nil
 }
 
-func (c *Client) UsersGetUser(userID string) (UserEntry, error) {
-	// TODO: add method paramter for field list
+// UsersGetUser returns a user. If fields are given, only those fields are
+// requested; each must be one modeled by UserEntry.
+func (c *Client) UsersGetUser(userID string, fields ...string) (UserEntry, error) {
 	// TODO: add method paramter for user_type
 
 	ue := UserEntry{}
 
+	if err := ValidateFields(fields, &ue); err != nil {
+		return ue, err
+	}
+
 	Url, err := url.Parse(fmt.Sprintf("%s/%s/%s", c.APIBaseURL, "users", userID))
 	if err != nil {
 		return ue, err
 	}
 	parameters := url.Values{}
-	// parameters.Add("fields", "id,name,login,status")
+	if len(fields) > 0 {
+		parameters.Add("fields", strings.Join(fields, ","))
+	}
 	Url.RawQuery = parameters.Encode()
 	// fmt.Println(Url.String())
 
@@ -215,16 +376,21 @@ func (c *Client) UsersGetUser(userID string) (UserEntry, error) {
 	return ue, nil
 }
 
-func (c *Client) UsersUpdateUser(userID string, u *UserEntry) (*UserEntry, error) {
+// UsersUpdateUser sends the settable, non-empty fields of updated to Box and
+// returns the user as Box now has it. updated itself is not modified.
+func (c *Client) UsersUpdateUser(userID string, updated *UserEntry) (*UserEntry, error) {
 	// TODO: add method paramter for field list
 
 	if userID == "" {
 		return nil, errors.New("No userID provided")
 	}
-	if u == nil {
+	if updated == nil {
 		return nil, errors.New("No updated UserEntry provided")
 	}
 
+	// Work on a copy so the caller's struct is left untouched
+	u := *updated
+
 	// Whitelist: remove un-settable attributes
 	u.Type = ""
 	u.ID = ""
@@ -234,11 +400,13 @@ func (c *Client) UsersUpdateUser(userID string, u *UserEntry) (*UserEntry, error
 	u.SpaceUsed = 0
 	u.MaxUploadSize = 0
 	u.AvatarURL = ""
+	u.Enterprise = nil // Setting this would move the user between enterprises
+	u.IsPlatformAccessOnly = false
 	if !stringInSlice(u.Status, []string{UserStatusActive, UserStatusInactive, UserStatusCannotDeleteEdit, UserStatusCannotDeleteEditUpload}) {
 		u.Status = ""
 	}
 
-	js, err := json.Marshal(u)
+	js, err := json.Marshal(&u)
 	if err != nil {
 		return nil, err
 	}
@@ -281,3 +449,162 @@ func (c *Client) UsersUpdateUser(userID string, u *UserEntry) (*UserEntry, error
 
 	return &ue, nil
 }
+
+// UsersGetCurrent returns the user the client is authenticated as (for the
+// default enterprise token, the service account).
+func (c *Client) UsersGetCurrent(fields ...string) (UserEntry, error) {
+	return c.UsersGetUser("me", fields...)
+}
+
+// WhoAmI returns the authenticated user including their enterprise, for
+// logging and sanity checking which account and enterprise a configuration
+// resolves to. Use TokenScopes for the access token's scopes.
+func (c *Client) WhoAmI() (*UserEntry, error) {
+	ue, err := c.UsersGetCurrent("type", "id", "name", "login", "status", "enterprise")
+	if err != nil {
+		return nil, err
+	}
+	return &ue, nil
+}
+
+// UsersGetByStatus returns all users with the given status (one of the
+// UserStatus* values). Box can't filter users by status, so users are
+// filtered as each page arrives.
+func (c *Client) UsersGetByStatus(status string) ([]*UserEntry, error) {
+	if !stringInSlice(status, []string{UserStatusActive, UserStatusInactive, UserStatusCannotDeleteEdit, UserStatusCannotDeleteEditUpload}) {
+		return nil, fmt.Errorf("Invalid user status: %q", status)
+	}
+
+	ues := []*UserEntry{}
+	err := c.usersForEach("id,name,login,status", func(ue *UserEntry) error {
+		if ue.Status == status {
+			ues = append(ues, ue)
+		}
+		return nil
+	})
+
+	return ues, err
+}
+
+// UsersGetAppUsers returns the enterprise's app users: the platform access
+// only users created by applications, as opposed to managed users.
+func (c *Client) UsersGetAppUsers() ([]*UserEntry, error) {
+	ues := []*UserEntry{}
+	err := c.usersForEach("id,name,login,status,is_platform_access_only", func(ue *UserEntry) error {
+		if ue.IsPlatformAccessOnly {
+			ues = append(ues, ue)
+		}
+		return nil
+	})
+
+	return ues, err
+}
+
+// usersForEach pages through all users, requesting the given comma separated
+// fields, and calls fn for each. If fn returns an error, paging stops and
+// that error is returned.
+func (c *Client) usersForEach(fields string, fn func(ue *UserEntry) error) error {
+	return c.usersForEachPage(fields, func(ur *UsersResponse, raw []byte) error {
+		for _, ue := range ur.Entries {
+			if err := fn(ue); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// usersForEachPage is usersForEach a page at a time. fn also gets the page's
+// raw response body, for callers that need the fields UserEntry doesn't model.
+func (c *Client) usersForEachPage(fields string, fn func(ur *UsersResponse, raw []byte) error) error {
+	offset := 0
+	limit := pageSize(c.PageSizes.Users, defaultUsersPageSize, maxUsersPageSize)
+
+	// Get all users, looping through API pages
+	for true {
+		var raw []byte
+		ur, err := c.usersGetPage(fields, offset, limit, CaptureRawResponse(&raw))
+		if err != nil {
+			return err
+		}
+
+		if err := fn(ur, raw); err != nil {
+			return err
+		}
+
+		// Use the values returned by the API response, not values passed in request
+		offset = ur.Offset + ur.Limit
+
+		if offset >= ur.TotalCount || len(ur.Entries) == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// UsersGetPage returns a single page of the enterprise's users, for callers
+// that drive pagination themselves. The next page starts at the returned
+// Offset + Limit, and there are no more once that reaches TotalCount. A limit
+// of 0 uses Box's default page size.
+func (c *Client) UsersGetPage(offset, limit int) (*UsersResponse, error) {
+	return c.usersGetPage("id,name,login,status", offset, limit)
+}
+
+// usersGetPage requests a page of users with the given comma separated fields,
+// or Box's default fields if fields is empty.
+func (c *Client) usersGetPage(fields string, offset, limit int, opts ...RequestOption) (*UsersResponse, error) {
+	if offset < 0 || limit < 0 {
+		return nil, fmt.Errorf("Invalid offset or limit: %d, %d", offset, limit)
+	}
+
+	parameters := url.Values{}
+	parameters.Add("user_type", "all") // May be unnecessary
+	if fields != "" {
+		parameters.Add("fields", fields)
+	}
+	parameters.Add("offset", fmt.Sprintf("%d", offset))
+	if limit > 0 {
+		parameters.Add("limit", fmt.Sprintf("%d", limit))
+	}
+
+	var ur UsersResponse
+	if err := c.DoJSON("GET", "users", parameters, nil, &ur, opts...); err != nil {
+		return nil, err
+	}
+
+	return &ur, nil
+}
+
+// UsersExportNDJSON writes every user in the enterprise to w as newline
+// delimited JSON, one user per line, a page at a time so memory use stays
+// bounded. Users are written as Box returns them, so fields not modeled by
+// UserEntry are kept. With no fields, Box's default fields are exported.
+func (c *Client) UsersExportNDJSON(w io.Writer, fields []string) error {
+	if w == nil {
+		return errors.New("No writer provided")
+	}
+
+	line := new(bytes.Buffer)
+	return c.usersForEachPage(strings.Join(fields, ","), func(ur *UsersResponse, raw []byte) error {
+		// Write users as Box returned them, not as decoded into UserEntry
+		var page struct {
+			Entries []json.RawMessage `json:"entries"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return err
+		}
+
+		for _, entry := range page.Entries {
+			line.Reset()
+			if err := json.Compact(line, entry); err != nil {
+				return err
+			}
+			line.WriteByte('\n')
+			if _, err := w.Write(line.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
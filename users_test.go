@@ -0,0 +1,50 @@
+package box
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestUsersExportNDJSON(t *testing.T) {
+	users := []string{
+		`{"type":"user","id":"1","login":"a@example.com","custom":{"x":1}}`,
+		`{"type":"user","id":"2","login":"b@example.com"}`,
+		`{"type":"user","id":"3","login":"c@example.com"}`,
+	}
+	c, _, done := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.URL.Query().Get("fields"); got != "id,login,custom" {
+			t.Errorf("fields = %q, want id,login,custom", got)
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		end := offset + limit
+		if end > len(users) {
+			end = len(users)
+		}
+		entries := new(bytes.Buffer)
+		for i, u := range users[offset:end] {
+			if i > 0 {
+				entries.WriteString(",")
+			}
+			entries.WriteString(u)
+		}
+		fmt.Fprintf(w, `{"total_count":%d,"offset":%d,"limit":%d,"entries":[%s]}`, len(users), offset, limit, entries)
+	})
+	defer done()
+	c.PageSizes.Users = 2
+
+	out := new(bytes.Buffer)
+	if err := c.UsersExportNDJSON(out, []string{"id", "login", "custom"}); err != nil {
+		t.Fatal(err)
+	}
+	want := users[0] + "\n" + users[1] + "\n" + users[2] + "\n"
+	if out.String() != want {
+		t.Errorf("exported:\n%s\nwant:\n%s", out, want)
+	}
+}
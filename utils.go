@@ -2,7 +2,18 @@ package box
 
 import (
 	"crypto/rand"
+	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
 )
 
 func stringInSlice(a string, list []string) bool {
@@ -14,6 +25,130 @@ func stringInSlice(a string, list []string) bool {
 	return false
 }
 
+// ValidateItemName checks a file or folder name against Box's naming rules,
+// so invalid names fail fast instead of with a generic 400 from the API. Per
+// Box: names must be 255 bytes or less, must not contain non-printable
+// ASCII characters or forward/backward slashes, must not end with a space, and
+// must not be "." or "..".
+func ValidateItemName(name string) error {
+	if name == "" {
+		return errors.New("Invalid item name: name is empty")
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("Invalid item name %q: not valid UTF-8", name)
+	}
+	if n := len(name); n > 255 {
+		return fmt.Errorf("Invalid item name %q: %d bytes exceeds the maximum of 255", name, n)
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("Invalid item name %q: must not contain / or \\", name)
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("Invalid item name %q: must not contain non-printable characters", name)
+		}
+	}
+	if strings.HasSuffix(name, " ") {
+		return fmt.Errorf("Invalid item name %q: must not end with a space", name)
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("Invalid item name %q: reserved name", name)
+	}
+	return nil
+}
+
+// UnknownFieldsError is returned when a requested field isn't one the result
+// type models. Box either ignores such fields or rejects the request with a
+// generic 400, and even when accepted their values would be silently dropped
+// while decoding.
+type UnknownFieldsError struct {
+	Fields []string
+	Known  []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("Unknown field(s): %s (known fields: %s)", strings.Join(e.Fields, ", "), strings.Join(e.Known, ", "))
+}
+
+// ValidateFields checks fields (as passed to Box's "fields" query parameter)
+// against the JSON field names of model, a struct or pointer to struct.
+func ValidateFields(fields []string, model interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	known := jsonFieldNames(model)
+	unknown := []string{}
+	for _, f := range fields {
+		if !stringInSlice(f, known) {
+			unknown = append(unknown, f)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return &UnknownFieldsError{Fields: unknown, Known: known}
+	}
+	return nil
+}
+
+// jsonFieldNames returns the sorted top-level JSON field names of a struct.
+func jsonFieldNames(model interface{}) []string {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	names := []string{}
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// conflictName returns the nth alternative for a name already taken in a
+// folder, e.g. "report (2).pdf". For files the number goes before the
+// extension.
+func conflictName(name string, n int, isFile bool) string {
+	ext := ""
+	if isFile {
+		ext = path.Ext(name)
+		if ext == name {
+			ext = ""
+		}
+	}
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// FileSHA1 returns the SHA1 digest of a local file in the form Box reports
+// it (FileEntry.Sha1): lowercase hex.
+func FileSHA1(localFilepath string) (string, error) {
+	f, err := os.Open(localFilepath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ReaderSHA1(f)
+}
+
+// ReaderSHA1 is FileSHA1 for everything r yields.
+func ReaderSHA1(r io.Reader) (string, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 // VIA https://stackoverflow.com/questions/32349807/how-can-i-generate-a-random-int-using-the-crypto-rand-package
 // GenerateRandomBytes returns securely generated random bytes.
 // It will return an error if the system's secure random
@@ -0,0 +1,117 @@
+package box
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WatermarkInfo is the watermark_info field of files and folders.
+type WatermarkInfo struct {
+	IsWatermarked bool `json:"is_watermarked"`
+}
+
+// Watermark is the watermark applied to a file or folder.
+type Watermark struct {
+	CreatedAt  string `json:"created_at,omitempty"`
+	ModifiedAt string `json:"modified_at,omitempty"`
+}
+
+type watermarkResponse struct {
+	Watermark *Watermark `json:"watermark"`
+}
+
+type watermarkRequest struct {
+	Watermark struct {
+		Imprint string `json:"imprint"`
+	} `json:"watermark"`
+}
+
+// FileGetWatermark returns the watermark applied to a file, or nil if the
+// file isn't watermarked. A file that doesn't exist is an error.
+func (c *Client) FileGetWatermark(fileID string) (*Watermark, error) {
+	return c.itemGetWatermark("files", fileID)
+}
+
+// FileApplyWatermark watermarks a file, so previews of it show the viewer's
+// identity. Applying a watermark to a watermarked file does nothing.
+func (c *Client) FileApplyWatermark(fileID string) error {
+	return c.itemApplyWatermark("files", fileID)
+}
+
+// FileRemoveWatermark removes the watermark from a file.
+func (c *Client) FileRemoveWatermark(fileID string) error {
+	return c.itemRemoveWatermark("files", fileID)
+}
+
+// FolderGetWatermark is the folder equivalent of FileGetWatermark.
+func (c *Client) FolderGetWatermark(folderID string) (*Watermark, error) {
+	return c.itemGetWatermark("folders", folderID)
+}
+
+// FolderApplyWatermark watermarks a folder, and with it every file in it.
+func (c *Client) FolderApplyWatermark(folderID string) error {
+	return c.itemApplyWatermark("folders", folderID)
+}
+
+// FolderRemoveWatermark removes the watermark from a folder.
+func (c *Client) FolderRemoveWatermark(folderID string) error {
+	return c.itemRemoveWatermark("folders", folderID)
+}
+
+// itemGetWatermark gets the watermark of the item at <collection>/<itemID>.
+// Box answers 404 both for items that aren't watermarked and for items that
+// don't exist or can't be seen, so a 404 is settled by looking at the item's
+// watermark_info.
+func (c *Client) itemGetWatermark(collection, itemID string) (*Watermark, error) {
+	if itemID == "" {
+		return nil, errors.New("No itemID provided")
+	}
+
+	var wr watermarkResponse
+	err := c.DoJSON("GET", fmt.Sprintf("%s/%s/watermark", collection, itemID), nil, nil, &wr)
+	if ae, ok := err.(*APIError); ok && ae.Status == http.StatusNotFound {
+		var item struct {
+			WatermarkInfo *WatermarkInfo `json:"watermark_info"`
+		}
+		if err := c.DoJSON("GET", fmt.Sprintf("%s/%s", collection, itemID), url.Values{"fields": {"watermark_info"}}, nil, &item); err != nil {
+			return nil, err
+		}
+		if item.WatermarkInfo != nil && !item.WatermarkInfo.IsWatermarked {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return wr.Watermark, nil
+}
+
+func (c *Client) itemApplyWatermark(collection, itemID string) error {
+	if itemID == "" {
+		return errors.New("No itemID provided")
+	}
+
+	// "default" is the only imprint Box supports
+	var wreq watermarkRequest
+	wreq.Watermark.Imprint = "default"
+
+	return c.DoJSON("PUT", fmt.Sprintf("%s/%s/watermark", collection, itemID), nil, &wreq, nil)
+}
+
+func (c *Client) itemRemoveWatermark(collection, itemID string) error {
+	if itemID == "" {
+		return errors.New("No itemID provided")
+	}
+
+	resp, err := c.Do("DELETE", fmt.Sprintf("%s/%s/watermark", collection, itemID), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	return nil
+}